@@ -5,27 +5,60 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"io"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"dagger.io/dagger"
+	"github.com/dagger/dagger/core/modules"
 	"github.com/dagger/dagger/engine/client"
 	"github.com/dagger/dagger/engine/client/pathutil"
 	"github.com/juju/ansiterm/tabwriter"
 	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
 )
 
 var (
 	generator      string
 	listJSONOutput bool
+	listFormat     string
+	listFilters    []string
+	clientOutput   string
+	clientDryRun   bool
+
+	clientUpgradeGenerator string
+	clientUpgradeVersion   string
+
+	clientInitName      string
+	clientInitGenerator string
+	clientInitOutput    string
 )
 
 func init() {
+	clientInstallCmd.Flags().StringVar(&clientOutput, "output", "", "Write the generated client to this path instead of the configured one, without updating the config (use - for stdout)")
+	clientInstallCmd.Flags().BoolVar(&clientDryRun, "dry-run", false, "Print the changes that would be made to the host without writing them")
+	clientUpgradeCmd.Flags().StringVar(&clientUpgradeGenerator, "generator", "", "Generator of the client to upgrade")
+	clientUpgradeCmd.Flags().StringVar(&clientUpgradeVersion, "version", "", "Version to upgrade the generator to (defaults to the latest compatible release)")
+	clientUpgradeCmd.MarkFlagRequired("generator")
+	clientInitCmd.Flags().StringVar(&clientInitName, "name", "", "Name of the new module (defaults to the current directory name)")
+	clientInitCmd.Flags().StringVar(&clientInitGenerator, "generator", "", "Generator to use for the client (ts, go, python or custom generator)")
+	clientInitCmd.Flags().StringVar(&clientInitOutput, "output", "dagger", "Path to generate the client at")
+	clientInitCmd.MarkFlagRequired("generator")
+	clientListCmd.Flags().StringVar(&listFormat, "format", "table", "Output format of the list of clients (table, json or yaml)")
 	clientListCmd.Flags().BoolVar(&listJSONOutput, "json", false, "Output the list of available clients in JSON format")
+	clientListCmd.Flags().MarkDeprecated("json", "use --format=json instead")
+	clientListCmd.Flags().StringArrayVar(&listFilters, "filter", nil, "Only list clients matching a key=value filter (supported keys: generator)")
 
+	clientCmd.AddCommand(clientInitCmd)
 	clientCmd.AddCommand(clientInstallCmd)
 	clientCmd.AddCommand(clientListCmd)
+	clientCmd.AddCommand(clientStatusCmd)
 	clientCmd.AddCommand(clientUninstallCmd)
 	clientCmd.AddCommand(clientUpdateCmd)
+	clientCmd.AddCommand(clientUpgradeCmd)
+	clientCmd.AddCommand(clientValidateCmd)
 }
 
 var clientCmd = &cobra.Command{
@@ -37,11 +70,108 @@ var clientCmd = &cobra.Command{
 	},
 }
 
+var clientInitCmd = &cobra.Command{
+	Use:   "init --generator <generator> [options]",
+	Short: "Initialize a new Dagger module with a generated client",
+	Long: `Initialize a new Dagger module with a generated client.
+
+The module is created in the current directory with a config that only
+declares the client, so a typed client can be generated before writing any
+module functions.`,
+	Example: "dagger client init --generator go --output ./dagger",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			cwd, err := pathutil.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current working directory: %w", err)
+			}
+
+			output := clientInitOutput
+			if filepath.IsAbs(output) {
+				output, err = filepath.Rel(cwd, output)
+				if err != nil {
+					return fmt.Errorf("failed to get relative path: %w", err)
+				}
+			}
+
+			dag := engineClient.Dagger()
+
+			modSrc := dag.ModuleSource(".", dagger.ModuleSourceOpts{
+				// the module is created in the current directory, even if it's
+				// nested in another module
+				DisableFindUp:  true,
+				AllowNotExists: true,
+				RequireKind:    dagger.ModuleSourceKindLocalSource,
+			})
+
+			alreadyExists, err := modSrc.ConfigExists(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to check if module already exists: %w", err)
+			}
+			if alreadyExists {
+				return fmt.Errorf("module already exists, use 'dagger client install' to add a client to it")
+			}
+
+			contextDirPath, err := modSrc.LocalContextDirectoryPath(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get local context directory path: %w", err)
+			}
+
+			name := clientInitName
+			if name == "" {
+				name = filepath.Base(cwd)
+			}
+
+			withClient := modSrc.
+				WithName(name).
+				WithEngineVersion(modules.EngineVersionLatest).
+				WithClient(clientInitGenerator, output)
+			lock, err := generatedClientsLock(ctx, dag, withClient)
+			if err != nil {
+				return err
+			}
+			_, err = withClient.
+				GeneratedContextDirectory().
+				Export(ctx, contextDirPath)
+			if err != nil {
+				return fmt.Errorf("failed to export client: %w", err)
+			}
+			if err := lock.write(); err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "Initialized module %s\n", name)
+			fmt.Fprintf(w, "Generated client at %s\n", output)
+
+			return nil
+		})
+	},
+	Annotations: map[string]string{
+		"experimental": "true",
+	},
+}
+
+// builtinClientGenerators are the generators bundled with the engine, offered
+// as completions of the generator argument of `dagger client install`.
+var builtinClientGenerators = []string{"go", "python", "typescript"}
+
 var clientInstallCmd = &cobra.Command{
 	Use:     "install [options] generator [path]",
 	Aliases: []string{"use"},
 	Short:   "Generate a new Dagger client from the Dagger module",
 	Example: "dagger client install go ./dagger",
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			// custom generators are module refs, which may be local paths
+			return builtinClientGenerators, cobra.ShellCompDirectiveDefault
+		case 1:
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
 			// default the output to the current working directory if it doesn't exist yet
@@ -80,15 +210,34 @@ var clientInstallCmd = &cobra.Command{
 				return fmt.Errorf("failed to get local context directory path: %w", err)
 			}
 
-			_, err = mod.Source.
-				WithClient(generator, outputPath).
-				GeneratedContextDirectory().
-				Export(ctx, contextDirPath)
+			withClient := mod.Source.WithClient(generator, outputPath)
+			generated := withClient.GeneratedContextDirectory()
+
+			w := cmd.OutOrStdout()
+
+			if clientDryRun {
+				if clientOutput != "" {
+					return fmt.Errorf("--dry-run cannot be used with --output, use --output - to preview the generated client")
+				}
+				return printGeneratedChanges(ctx, w, mod.Source, generated)
+			}
+
+			if clientOutput != "" {
+				return exportClientTo(ctx, w, dag, generated.Directory(outputPath), contextDirPath, clientOutput)
+			}
+
+			lock, err := generatedClientsLock(ctx, dag, withClient)
+			if err != nil {
+				return err
+			}
+			_, err = generated.Export(ctx, contextDirPath)
 			if err != nil {
 				return fmt.Errorf("failed to export client: %w", err)
 			}
+			if err := lock.write(); err != nil {
+				return err
+			}
 
-			w := cmd.OutOrStdout()
 			fmt.Fprintf(w, "Generated client at %s\n", outputPath)
 
 			return nil
@@ -154,14 +303,136 @@ var clientUninstallCmd = &cobra.Command{
 	},
 }
 
+// exportClientTo writes the files of a generated client to dest rather than to
+// its configured path. If dest is "-", the files are written to w as a patch.
+func exportClientTo(ctx context.Context, w io.Writer, dag *dagger.Client, clientDir *dagger.Directory, contextDirPath, dest string) error {
+	if dest == "-" {
+		patch, err := dag.Directory().
+			WithDirectory(".", clientDir).
+			Changes(dag.Directory()).
+			AsPatch().
+			Contents(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to render client: %w", err)
+		}
+		_, err = fmt.Fprint(w, patch)
+		return err
+	}
+
+	absDest, err := pathutil.Abs(dest)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of %s: %w", dest, err)
+	}
+	relDest, err := filepath.Rel(contextDirPath, absDest)
+	if err != nil || relDest == ".." || strings.HasPrefix(relDest, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("output path %s is outside of the workspace %s", dest, contextDirPath)
+	}
+
+	_, err = clientDir.Export(ctx, absDest)
+	if err != nil {
+		return fmt.Errorf("failed to export client: %w", err)
+	}
+
+	fmt.Fprintf(w, "Generated client at %s\n", dest)
+	return nil
+}
+
+// generatedChanges returns the changes that exporting the generated context
+// directory would make to the context directory of the module source.
+func generatedChanges(src *dagger.ModuleSource, generated *dagger.Directory) *dagger.Changeset {
+	// the generated context directory only contains the generated files, so
+	// layer it on top of the current context directory to compare
+	contextDir := src.ContextDirectory()
+	return contextDir.WithDirectory(".", generated).Changes(contextDir)
+}
+
+// printGeneratedChanges prints the files that exporting the generated context
+// directory would write, followed by a diff of their contents.
+func printGeneratedChanges(ctx context.Context, w io.Writer, src *dagger.ModuleSource, generated *dagger.Directory) error {
+	changes := generatedChanges(src, generated)
+	added, err := changes.AddedPaths(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get added paths: %w", err)
+	}
+	modified, err := changes.ModifiedPaths(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get modified paths: %w", err)
+	}
+	if len(added) == 0 && len(modified) == 0 {
+		_, _ = fmt.Fprintln(w, "no changes")
+		return nil
+	}
+	for _, path := range added {
+		fmt.Fprintf(w, "would write %s\n", path)
+	}
+	for _, path := range modified {
+		fmt.Fprintf(w, "would modify %s\n", path)
+	}
+
+	patch, err := changes.AsPatch().Contents(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to diff generated files: %w", err)
+	}
+	_, err = fmt.Fprint(w, "\n"+patch)
+	return err
+}
+
 //go:embed clientconf.graphql
 var loadModClientConfQuery string
 
+// configClient is a client entry of the module config.
+type configClient struct {
+	Generator string `json:"Generator" yaml:"Generator"`
+	Directory string `json:"Directory" yaml:"Directory"`
+}
+
+func loadConfigClients(ctx context.Context, dag *dagger.Client, src *dagger.ModuleSource) ([]configClient, error) {
+	moduleSourceID, err := src.ID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module source id: %w", err)
+	}
+
+	var res struct {
+		Source struct {
+			ConfigClients []configClient
+		}
+	}
+
+	err = dag.Do(ctx, &dagger.Request{
+		Query: loadModClientConfQuery,
+		Variables: map[string]any{
+			"source": moduleSourceID,
+		},
+	}, &dagger.Response{
+		Data: &res,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module source config clients: %w", err)
+	}
+
+	return res.Source.ConfigClients, nil
+}
+
 var clientListCmd = &cobra.Command{
-	Use:     "list",
-	Short:   "List all Dagger clients installed in the current module",
-	Example: "dagger client list",
+	Use:   "list [--format <format>] [--filter <key>=<value>...]",
+	Short: "List all Dagger clients installed in the current module",
+	Long: `List all Dagger clients installed in the current module.
+
+Use --filter to only list the clients matching all of the given filters. The
+generator filter matches clients using the given generator, at any version
+unless one is given with @<version>.`,
+	Example: `dagger client list
+dagger client list --format yaml
+dagger client list --filter generator=go`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if listJSONOutput {
+			listFormat = "json"
+		}
+		formatter, ok := clientFormatters[listFormat]
+		if !ok {
+			return fmt.Errorf("unsupported format %q (supported formats: table, json, yaml)", listFormat)
+		}
+
 		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
 			dag := engineClient.Dagger()
 
@@ -170,52 +441,103 @@ var clientListCmd = &cobra.Command{
 				return fmt.Errorf("failed to initialize client generator module: %w", err)
 			}
 
-			moduleSourceID, err := mod.Source.ID(ctx)
+			clients, err := loadConfigClients(ctx, dag, mod.Source)
 			if err != nil {
-				return fmt.Errorf("failed to list clients: failed to get module source id: %w", err)
+				return fmt.Errorf("failed to list clients: %w", err)
 			}
 
-			var res struct {
-				Source struct {
-					ConfigClients []struct {
-						Generator string
-						Directory string
-					}
-				}
+			clients, err = filterConfigClients(clients, listFilters)
+			if err != nil {
+				return err
 			}
 
-			err = dag.Do(ctx, &dagger.Request{
-				Query: loadModClientConfQuery,
-				Variables: map[string]any{
-					"source": moduleSourceID,
-				},
-			}, &dagger.Response{
-				Data: &res,
-			})
+			return formatter.Format(cmd.OutOrStdout(), clients)
+		})
+	},
+}
 
-			if err != nil {
-				return fmt.Errorf("failed to list clients: failed to get module source config clients: %w", err)
-			}
+// ClientFormatter writes a list of clients in some output format.
+type ClientFormatter interface {
+	Format(w io.Writer, clients []configClient) error
+}
 
-			if listJSONOutput {
-				clientsContent, err := json.Marshal(res.Source.ConfigClients)
-				if err != nil {
-					return fmt.Errorf("failed to marshal clients results: %w", err)
-				}
+// clientFormatters are the supported values of the --format flag of
+// `dagger client list`.
+var clientFormatters = map[string]ClientFormatter{
+	"table": tableClientFormatter{},
+	"json":  jsonClientFormatter{},
+	"yaml":  yamlClientFormatter{},
+}
 
-				cmd.OutOrStdout().Write(clientsContent)
-				return nil
-			}
+type tableClientFormatter struct{}
 
-			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', tabwriter.DiscardEmptyColumns)
-			fmt.Fprintf(tw, "GENERATOR\tPATH\n")
-			for _, client := range res.Source.ConfigClients {
-				fmt.Fprintf(tw, "%s\t%s\n", client.Generator, client.Directory)
-			}
+func (tableClientFormatter) Format(w io.Writer, clients []configClient) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', tabwriter.DiscardEmptyColumns)
+	fmt.Fprintf(tw, "GENERATOR\tPATH\n")
+	for _, client := range clients {
+		fmt.Fprintf(tw, "%s\t%s\n", client.Generator, client.Directory)
+	}
+	return tw.Flush()
+}
 
-			return tw.Flush()
-		})
-	},
+type jsonClientFormatter struct{}
+
+func (jsonClientFormatter) Format(w io.Writer, clients []configClient) error {
+	clientsContent, err := json.Marshal(clients)
+	if err != nil {
+		return fmt.Errorf("failed to marshal clients results: %w", err)
+	}
+	_, err = w.Write(clientsContent)
+	return err
+}
+
+type yamlClientFormatter struct{}
+
+func (yamlClientFormatter) Format(w io.Writer, clients []configClient) error {
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(clients); err != nil {
+		return fmt.Errorf("failed to marshal clients results: %w", err)
+	}
+	return enc.Close()
+}
+
+// filterConfigClients returns the clients matching all of the given key=value
+// filters.
+func filterConfigClients(clients []configClient, filters []string) ([]configClient, error) {
+	var generators []string
+	for _, filter := range filters {
+		key, value, found := strings.Cut(filter, "=")
+		if !found {
+			return nil, fmt.Errorf("filter %q must be formatted as key=value", filter)
+		}
+		switch key {
+		case "generator":
+			generators = append(generators, value)
+		default:
+			return nil, fmt.Errorf("unsupported filter key %q (supported keys: generator)", key)
+		}
+	}
+
+	var matched []configClient
+	for _, client := range clients {
+		if !slices.ContainsFunc(generators, func(generator string) bool {
+			return !generatorMatches(client.Generator, generator)
+		}) {
+			matched = append(matched, client)
+		}
+	}
+	return matched, nil
+}
+
+// generatorMatches returns true if the generator of a client matches the
+// given one, ignoring the version of the client's generator if the given one
+// has none.
+func generatorMatches(clientGenerator, generator string) bool {
+	if strings.Contains(generator, "@") {
+		return clientGenerator == generator
+	}
+	source, _, _ := strings.Cut(clientGenerator, "@")
+	return source == generator
 }
 
 var clientUpdateCmd = &cobra.Command{
@@ -236,13 +558,20 @@ var clientUpdateCmd = &cobra.Command{
 				return fmt.Errorf("failed to get local context directory path: %w", err)
 			}
 
-			_, err = mod.Source.
-				WithUpdatedClients(args).
+			updated := mod.Source.WithUpdatedClients(args)
+			lock, err := generatedClientsLock(ctx, dag, updated)
+			if err != nil {
+				return err
+			}
+			_, err = updated.
 				GeneratedContextDirectory().
 				Export(ctx, contextDirPath)
 			if err != nil {
 				return fmt.Errorf("failed to update clients: %w", err)
 			}
+			if err := lock.write(); err != nil {
+				return err
+			}
 
 			w := cmd.OutOrStdout()
 			_, _ = fmt.Fprintln(w, "clients updated")
@@ -251,3 +580,237 @@ var clientUpdateCmd = &cobra.Command{
 		})
 	},
 }
+
+var clientUpgradeCmd = &cobra.Command{
+	Use:   "upgrade --generator <generator> [--version <version>]",
+	Short: "Upgrade the generator of a Dagger client and regenerate it",
+	Long: `Upgrade the generator of a Dagger client and regenerate it.
+
+If no version is given, the generator is upgraded to its latest release with
+the same major version as the one currently configured.`,
+	Example: "dagger client upgrade --generator github.com/shykes/x/hello --version v0.3.0",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			dag := engineClient.Dagger()
+
+			mod, err := initializeClientGeneratorModule(ctx, dag, ".")
+			if err != nil {
+				return fmt.Errorf("failed to initialize client generator module: %w", err)
+			}
+
+			clients, err := loadConfigClients(ctx, dag, mod.Source)
+			if err != nil {
+				return fmt.Errorf("failed to upgrade client: %w", err)
+			}
+
+			source, _, _ := strings.Cut(clientUpgradeGenerator, "@")
+			var currentVersion string
+			var found bool
+			for _, client := range clients {
+				clientSource, clientVersion, _ := strings.Cut(client.Generator, "@")
+				if clientSource == source {
+					currentVersion = clientVersion
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("no client generated with %s in the module config", source)
+			}
+
+			version := clientUpgradeVersion
+			if version == "" {
+				version, err = latestGeneratorRelease(ctx, dag, source, currentVersion)
+				if err != nil {
+					return fmt.Errorf("failed to resolve latest release of %s: %w", source, err)
+				}
+			}
+
+			contextDirPath, err := mod.Source.LocalContextDirectoryPath(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get local context directory path: %w", err)
+			}
+
+			upgraded := mod.Source.WithUpdatedClients([]string{source + "@" + version})
+			lock, err := generatedClientsLock(ctx, dag, upgraded)
+			if err != nil {
+				return err
+			}
+			_, err = upgraded.
+				GeneratedContextDirectory().
+				Export(ctx, contextDirPath)
+			if err != nil {
+				return fmt.Errorf("failed to upgrade client: %w", err)
+			}
+			if err := lock.write(); err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "Upgraded %s to %s\n", source, version)
+
+			return nil
+		})
+	},
+}
+
+// latestGeneratorRelease returns the highest semver tag of a git generator
+// that has the same major version as the current one, if it is a release.
+func latestGeneratorRelease(ctx context.Context, dag *dagger.Client, source, currentVersion string) (string, error) {
+	src := dag.ModuleSource(source)
+	kind, err := src.Kind(ctx)
+	if err != nil {
+		return "", err
+	}
+	if kind != dagger.ModuleSourceKindGitSource {
+		return "", fmt.Errorf("only generators from git can be upgraded")
+	}
+	cloneRef, err := src.CloneRef(ctx)
+	if err != nil {
+		return "", err
+	}
+	subpath, err := src.SourceRootSubpath(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// releases of modules in a subdirectory are tagged as <subpath>/<version>
+	var prefix string
+	if subpath != "" && subpath != "." {
+		prefix = subpath + "/"
+	}
+	tags, err := dag.Git(cloneRef).Tags(ctx, dagger.GitRepositoryTagsOpts{
+		Patterns: []string{"refs/tags/" + prefix + "v*"},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	latest := latestReleaseTag(tags, prefix, currentVersion)
+	if latest == "" {
+		return "", fmt.Errorf("no release found")
+	}
+
+	return latest, nil
+}
+
+// latestReleaseTag returns the highest semver version among the given tags
+// that start with prefix, skipping prereleases and, if currentVersion is a
+// semver version, versions with another major version. It returns "" if no
+// tag matches.
+func latestReleaseTag(tags []string, prefix, currentVersion string) string {
+	var latest string
+	for _, tag := range tags {
+		version, ok := strings.CutPrefix(strings.TrimPrefix(tag, "refs/tags/"), prefix)
+		if !ok || !semver.IsValid(version) || semver.Prerelease(version) != "" {
+			continue
+		}
+		if semver.IsValid(currentVersion) && semver.Major(version) != semver.Major(currentVersion) {
+			continue
+		}
+		if latest == "" || semver.Compare(version, latest) > 0 {
+			latest = version
+		}
+	}
+	return latest
+}
+
+var clientStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the generated clients of the current module are stale",
+	Long: `Show whether the generated clients of the current module are stale.
+
+The schema of the module is compared to the one each client was last generated
+from, as recorded in ` + clientsLockFile + ` by dagger client init, install,
+update and upgrade. Unlike dagger client validate, the clients are not re-generated, so
+changes to the generators themselves or to the files on disk are not detected.
+
+If any client is stale, the command exits with a non-zero status.`,
+	Example: "dagger client status",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			dag := engineClient.Dagger()
+
+			mod, err := initializeClientGeneratorModule(ctx, dag, ".")
+			if err != nil {
+				return fmt.Errorf("failed to initialize client generator module: %w", err)
+			}
+
+			clients, err := loadConfigClients(ctx, dag, mod.Source)
+			if err != nil {
+				return fmt.Errorf("failed to list clients: %w", err)
+			}
+
+			lockPath, err := clientsLockPath(ctx, mod.Source)
+			if err != nil {
+				return err
+			}
+			lock, err := readClientsLock(lockPath)
+			if err != nil {
+				return err
+			}
+
+			schemaDigest, err := moduleSchemaDigest(ctx, dag, mod.Source)
+			if err != nil {
+				return err
+			}
+
+			var stale int
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', tabwriter.DiscardEmptyColumns)
+			fmt.Fprintf(tw, "GENERATOR\tPATH\tSTATUS\n")
+			for _, client := range clients {
+				status := "up-to-date"
+				if !lock.upToDate(client, schemaDigest) {
+					status = "stale"
+					stale++
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", client.Generator, client.Directory, status)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+
+			if stale > 0 {
+				return fmt.Errorf("%d generated clients are stale, run 'dagger client update' to regenerate them", stale)
+			}
+			return nil
+		})
+	},
+}
+
+var clientValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check that the generated clients of the current module are up-to-date",
+	Long: `Check that the generated clients of the current module are up-to-date.
+
+The clients are re-generated without touching the host, and compared to the
+files on disk. If they differ, the difference is printed and the command exits
+with a non-zero status, which makes it suitable for enforcing freshness in CI.`,
+	Example: "dagger client validate",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			dag := engineClient.Dagger()
+
+			mod, err := initializeClientGeneratorModule(ctx, dag, ".")
+			if err != nil {
+				return fmt.Errorf("failed to initialize client generator module: %w", err)
+			}
+
+			patch, err := generatedChanges(mod.Source, mod.Source.GeneratedContextDirectory()).
+				AsPatch().
+				Contents(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to diff generated clients: %w", err)
+			}
+
+			w := cmd.OutOrStdout()
+			if patch == "" {
+				_, _ = fmt.Fprintln(w, "clients are up-to-date")
+				return nil
+			}
+
+			_, _ = fmt.Fprint(w, patch)
+			return fmt.Errorf("generated clients are out-of-date, run 'dagger client update' to regenerate them")
+		})
+	},
+}
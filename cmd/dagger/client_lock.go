@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"dagger.io/dagger"
+	"github.com/opencontainers/go-digest"
+)
+
+// clientsLockFile is the name of the file, next to dagger.json, that records
+// the schema each client was last generated from.
+const clientsLockFile = "dagger-clients.lock"
+
+// clientsLock is the content of the clientsLockFile.
+type clientsLock struct {
+	Clients []clientLock `json:"clients"`
+
+	// path is where the lock is written, if it's a new one.
+	path string
+}
+
+// clientLock records the schema a client was last generated from.
+type clientLock struct {
+	Generator    string `json:"generator"`
+	Directory    string `json:"directory"`
+	SchemaDigest string `json:"schemaDigest"`
+}
+
+// upToDate returns true if the client was last generated from the given
+// schema, with its current generator.
+func (lock clientsLock) upToDate(client configClient, schemaDigest string) bool {
+	for _, entry := range lock.Clients {
+		if filepath.Clean(entry.Directory) == filepath.Clean(client.Directory) {
+			return entry.Generator == client.Generator && entry.SchemaDigest == schemaDigest
+		}
+	}
+	return false
+}
+
+// clientsLockPath returns the path of the clientsLockFile of a local module
+// source.
+func clientsLockPath(ctx context.Context, src *dagger.ModuleSource) (string, error) {
+	contextDirPath, err := src.LocalContextDirectoryPath(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get local context directory path: %w", err)
+	}
+	sourceRootSubpath, err := src.SourceRootSubpath(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source root subpath: %w", err)
+	}
+	return filepath.Join(contextDirPath, sourceRootSubpath, clientsLockFile), nil
+}
+
+func readClientsLock(path string) (clientsLock, error) {
+	var lock clientsLock
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return lock, nil
+		}
+		return lock, err
+	}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return lock, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+// moduleSchemaDigest returns a digest of the schema that clients of the module
+// are generated from, i.e. the core API extended with the module and its
+// dependencies.
+func moduleSchemaDigest(ctx context.Context, dag *dagger.Client, src *dagger.ModuleSource) (string, error) {
+	err := src.AsModule().Serve(ctx, dagger.ModuleServeOpts{IncludeDependencies: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to serve module: %w", err)
+	}
+
+	var res json.RawMessage
+	err = dag.Do(ctx, &dagger.Request{
+		Query: loadTypeDefsQuery,
+	}, &dagger.Response{
+		Data: &res,
+	})
+	if err != nil {
+		return "", fmt.Errorf("query module schema: %w", err)
+	}
+	return digest.FromBytes(res).String(), nil
+}
+
+// generatedClientsLock returns the clientsLockFile recording the clients of a
+// module source as generated from its current schema.
+//
+// Computing the schema serves the module, which may fail, so the lock must be
+// created before the generated clients are exported, and written after.
+func generatedClientsLock(ctx context.Context, dag *dagger.Client, src *dagger.ModuleSource) (clientsLock, error) {
+	clients, err := loadConfigClients(ctx, dag, src)
+	if err != nil {
+		return clientsLock{}, fmt.Errorf("failed to load clients: %w", err)
+	}
+	schemaDigest, err := moduleSchemaDigest(ctx, dag, src)
+	if err != nil {
+		return clientsLock{}, err
+	}
+	path, err := clientsLockPath(ctx, src)
+	if err != nil {
+		return clientsLock{}, err
+	}
+
+	lock := clientsLock{
+		Clients: make([]clientLock, 0, len(clients)),
+		path:    path,
+	}
+	for _, client := range clients {
+		lock.Clients = append(lock.Clients, clientLock{
+			Generator:    client.Generator,
+			Directory:    client.Directory,
+			SchemaDigest: schemaDigest,
+		})
+	}
+	return lock, nil
+}
+
+// write writes a lock returned by generatedClientsLock to its module.
+func (lock clientsLock) write() error {
+	content, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(lock.path, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", clientsLockFile, err)
+	}
+	return nil
+}
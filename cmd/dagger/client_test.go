@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestReleaseTag(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		tags           []string
+		prefix         string
+		currentVersion string
+		want           string
+	}{
+		{
+			name: "highest version",
+			tags: []string{"refs/tags/v0.1.0", "refs/tags/v0.10.0", "refs/tags/v0.2.0"},
+			want: "v0.10.0",
+		},
+		{
+			name: "no tags",
+			want: "",
+		},
+		{
+			name: "skips prereleases",
+			tags: []string{"refs/tags/v0.1.0", "refs/tags/v0.2.0-rc.1"},
+			want: "v0.1.0",
+		},
+		{
+			name: "skips non-semver tags",
+			tags: []string{"refs/tags/v0.1.0", "refs/tags/vnext", "refs/tags/1.0.0"},
+			want: "v0.1.0",
+		},
+		{
+			name:           "same major version",
+			tags:           []string{"refs/tags/v1.2.0", "refs/tags/v1.3.1", "refs/tags/v2.0.0"},
+			currentVersion: "v1.2.0",
+			want:           "v1.3.1",
+		},
+		{
+			name:           "no release with the same major version",
+			tags:           []string{"refs/tags/v2.0.0"},
+			currentVersion: "v1.2.0",
+			want:           "",
+		},
+		{
+			name:           "any major version from a branch",
+			tags:           []string{"refs/tags/v1.2.0", "refs/tags/v2.0.0"},
+			currentVersion: "main",
+			want:           "v2.0.0",
+		},
+		{
+			name:   "subpath prefix",
+			tags:   []string{"refs/tags/gen/v0.1.0", "refs/tags/v0.9.0", "refs/tags/other/v0.5.0"},
+			prefix: "gen/",
+			want:   "v0.1.0",
+		},
+		{
+			name: "no subpath prefix",
+			tags: []string{"refs/tags/gen/v0.1.0", "refs/tags/v0.2.0"},
+			want: "v0.2.0",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, latestReleaseTag(test.tags, test.prefix, test.currentVersion))
+		})
+	}
+}
@@ -1263,6 +1263,172 @@ func main() {
 		require.JSONEq(t, `[{"Generator":"go","Directory":"./dagger"},{"Generator":"go","Directory":"./dagger2"}]`, out)
 	})
 
+	t.Run("list clients in json format", func(ctx context.Context, t *testctx.T) {
+		out, err := moduleSrc.WithExec([]string{"dagger", "client", "list", "--format", "json"}).Stdout(ctx)
+
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"Generator":"go","Directory":"./dagger"},{"Generator":"go","Directory":"./dagger2"}]`, out)
+	})
+
+	t.Run("list clients in yaml format", func(ctx context.Context, t *testctx.T) {
+		out, err := moduleSrc.WithExec([]string{"dagger", "client", "list", "--format", "yaml"}).Stdout(ctx)
+
+		require.NoError(t, err)
+		require.YAMLEq(t, `[{Generator: go, Directory: ./dagger}, {Generator: go, Directory: ./dagger2}]`, out)
+	})
+
+	t.Run("list clients with a filter", func(ctx context.Context, t *testctx.T) {
+		out, err := moduleSrc.WithExec([]string{"dagger", "client", "list", "--format=json", "--filter", "generator=go"}).Stdout(ctx)
+
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"Generator":"go","Directory":"./dagger"},{"Generator":"go","Directory":"./dagger2"}]`, out)
+
+		_, err = moduleSrc.WithExec([]string{"dagger", "client", "list", "--filter", "generator"}).Stdout(ctx)
+		requireErrOut(t, err, `filter "generator" must be formatted as key=value`)
+
+		_, err = moduleSrc.WithExec([]string{"dagger", "client", "list", "--filter", "path=./dagger"}).Stdout(ctx)
+		requireErrOut(t, err, `unsupported filter key "path" (supported keys: generator)`)
+	})
+
+	type clientsLock struct {
+		Clients []struct {
+			Generator    string `json:"generator"`
+			Directory    string `json:"directory"`
+			SchemaDigest string `json:"schemaDigest"`
+		} `json:"clients"`
+	}
+
+	t.Run("clients lock", func(ctx context.Context, t *testctx.T) {
+		content, err := moduleSrc.File("dagger-clients.lock").Contents(ctx)
+		require.NoError(t, err)
+
+		var lock clientsLock
+		require.NoError(t, json.Unmarshal([]byte(content), &lock))
+		require.Len(t, lock.Clients, 2)
+		require.Equal(t, "go", lock.Clients[0].Generator)
+		require.Equal(t, "./dagger", lock.Clients[0].Directory)
+		require.Equal(t, "go", lock.Clients[1].Generator)
+		require.Equal(t, "./dagger2", lock.Clients[1].Directory)
+		require.NotEmpty(t, lock.Clients[0].SchemaDigest)
+		require.Equal(t, lock.Clients[0].SchemaDigest, lock.Clients[1].SchemaDigest)
+	})
+
+	t.Run("clients status", func(ctx context.Context, t *testctx.T) {
+		out, err := moduleSrc.WithExec([]string{"dagger", "client", "status"}).Stdout(ctx)
+
+		require.NoError(t, err)
+		require.Regexp(t, `go\s+\./dagger\s+up-to-date\n`, out)
+		require.Regexp(t, `go\s+\./dagger2\s+up-to-date\n`, out)
+		require.NotContains(t, out, "stale")
+	})
+
+	t.Run("clients status after the module changes", func(ctx context.Context, t *testctx.T) {
+		_, err := moduleSrc.
+			With(daggerNonNestedExec("uninstall", "hello")).
+			WithExec([]string{"dagger", "client", "status"}).
+			Stdout(ctx)
+
+		requireErrOut(t, err, "2 generated clients are stale")
+	})
+
+	t.Run("clients status after the lock changes", func(ctx context.Context, t *testctx.T) {
+		content, err := moduleSrc.File("dagger-clients.lock").Contents(ctx)
+		require.NoError(t, err)
+
+		var lock clientsLock
+		require.NoError(t, json.Unmarshal([]byte(content), &lock))
+		require.Len(t, lock.Clients, 2)
+		lock.Clients[0].SchemaDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+		edited, err := json.Marshal(lock)
+		require.NoError(t, err)
+
+		_, err = moduleSrc.
+			WithNewFile("dagger-clients.lock", string(edited)).
+			WithExec([]string{"dagger", "client", "status"}).
+			Stdout(ctx)
+
+		requireErrOut(t, err, "1 generated clients are stale")
+	})
+
+	t.Run("validate clients", func(ctx context.Context, t *testctx.T) {
+		out, err := moduleSrc.WithExec([]string{"dagger", "client", "validate"}).Stdout(ctx)
+
+		require.NoError(t, err)
+		require.Equal(t, "clients are up-to-date\n", out)
+	})
+
+	t.Run("validate edited clients", func(ctx context.Context, t *testctx.T) {
+		_, err := moduleSrc.
+			WithExec([]string{"sh", "-c", "echo '// edited by hand' >> dagger/dagger.gen.go"}).
+			WithExec([]string{"dagger", "client", "validate"}).
+			Stdout(ctx)
+
+		requireErrOut(t, err, "generated clients are out-of-date, run 'dagger client update' to regenerate them")
+		requireErrOut(t, err, "dagger/dagger.gen.go")
+		requireErrOut(t, err, "-// edited by hand")
+	})
+
+	t.Run("install client to an output path outside of the workspace", func(ctx context.Context, t *testctx.T) {
+		_, err := moduleSrc.WithExec([]string{"dagger", "client", "install", "go", "./dagger3", "--output", "../outside"}).Stdout(ctx)
+
+		requireErrOut(t, err, "output path ../outside is outside of the workspace /work")
+	})
+
+	t.Run("install client to stdout", func(ctx context.Context, t *testctx.T) {
+		config, err := moduleSrc.File("dagger.json").Contents(ctx)
+		require.NoError(t, err)
+
+		ctr := moduleSrc.WithExec([]string{"dagger", "client", "install", "go", "./dagger3", "--output", "-"})
+		out, err := ctr.Stdout(ctx)
+
+		require.NoError(t, err)
+		require.Contains(t, out, "dagger.gen.go")
+		require.NotContains(t, out, "Generated client at")
+
+		after, err := ctr.File("dagger.json").Contents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, config, after)
+		_, err = ctr.Directory("dagger3").Entries(ctx)
+		require.Error(t, err)
+	})
+
+	t.Run("install client with dry-run", func(ctx context.Context, t *testctx.T) {
+		config, err := moduleSrc.File("dagger.json").Contents(ctx)
+		require.NoError(t, err)
+
+		ctr := moduleSrc.WithExec([]string{"dagger", "client", "install", "go", "./dagger3", "--dry-run"})
+		out, err := ctr.Stdout(ctx)
+
+		require.NoError(t, err)
+		require.Contains(t, out, "would write dagger3/dagger.gen.go\n")
+		require.Contains(t, out, "would modify dagger.json\n")
+		require.NotContains(t, out, "Generated client at")
+
+		after, err := ctr.File("dagger.json").Contents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, config, after)
+		_, err = ctr.Directory("dagger3").Entries(ctx)
+		require.Error(t, err)
+	})
+
+	t.Run("install client to an output path with dry-run", func(ctx context.Context, t *testctx.T) {
+		_, err := moduleSrc.WithExec([]string{"dagger", "client", "install", "go", "./dagger3", "--output", "./out", "--dry-run"}).Stdout(ctx)
+
+		requireErrOut(t, err, "--dry-run cannot be used with --output, use --output - to preview the generated client")
+	})
+
+	t.Run("complete install arguments", func(ctx context.Context, t *testctx.T) {
+		out, err := moduleSrc.WithExec([]string{"dagger", "__complete", "client", "install", ""}).Stdout(ctx)
+
+		require.NoError(t, err)
+		require.Equal(t, "go\npython\ntypescript\n:0\n", out)
+
+		out, err = moduleSrc.WithExec([]string{"dagger", "__complete", "client", "install", "go", ""}).Stdout(ctx)
+
+		require.NoError(t, err)
+		require.Equal(t, ":16\n", out)
+	})
+
 	t.Run("uninstall client", func(ctx context.Context, t *testctx.T) {
 		ctr := moduleSrc.WithExec([]string{"dagger", "client", "uninstall", "dagger"})
 
@@ -1447,6 +1613,94 @@ func (ClientGeneratorTest) TestClientUpdate(ctx context.Context, t *testctx.T) {
 	}
 }
 
+func (ClientGeneratorTest) TestClientUpgrade(ctx context.Context, t *testctx.T) {
+	// pin from github.com/dagger/client-generator-test module
+	const v01Pin = "446f2691deba58f99b55b86430fade1c773e486f"
+
+	c := connect(ctx, t)
+
+	modCtr := c.Container().From("alpine:3.20.2").
+		WithMountedFile(testCLIBinPath, daggerCliFile(t, c)).
+		WithWorkdir("/work").
+		With(daggerExec("init")).
+		WithNewFile("/work/dagger.json", `{
+	"name": "test",
+	"clients": [
+		{
+			"generator": "github.com/dagger/client-generator-test@`+v01Pin+`",
+			"directory": "dagger"
+		}
+	]
+}`)
+
+	t.Run("upgrade to a version", func(ctx context.Context, t *testctx.T) {
+		ctr := modCtr.With(daggerExec("client", "upgrade", "--generator", "github.com/dagger/client-generator-test", "--version", "v0.0.2"))
+
+		out, err := ctr.Stdout(ctx)
+		require.NoError(t, err)
+		require.Contains(t, out, "Upgraded github.com/dagger/client-generator-test to v0.0.2\n")
+
+		daggerjson, err := ctr.File("dagger.json").Contents(ctx)
+		require.NoError(t, err)
+		require.Contains(t, daggerjson, "github.com/dagger/client-generator-test@v0.0.2")
+		require.NotContains(t, daggerjson, v01Pin)
+	})
+
+	t.Run("upgrade a client not in the dagger.json", func(ctx context.Context, t *testctx.T) {
+		_, err := modCtr.
+			With(daggerExec("client", "upgrade", "--generator", "github.com/dagger/other-generator", "--version", "v0.0.2")).
+			Stdout(ctx)
+
+		requireErrOut(t, err, "no client generated with github.com/dagger/other-generator in the module config")
+	})
+}
+
+func (ClientGeneratorTest) TestClientInit(ctx context.Context, t *testctx.T) {
+	t.Run("init in an empty directory", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		modCtr := c.Container().From(golangImage).
+			WithMountedFile(testCLIBinPath, daggerCliFile(t, c)).
+			WithWorkdir("/work").
+			WithEnvVariable("_EXPERIMENTAL_DAGGER_CLI_BIN", "/bin/dagger").
+			With(nonNestedDevEngine(c)).
+			With(daggerNonNestedExec("client", "init", "--generator", "go", "--name", "test"))
+
+		out, err := modCtr.Stdout(ctx)
+		require.NoError(t, err)
+		require.Contains(t, out, "Initialized module test\nGenerated client at dagger\n")
+
+		generatedFiles, err := modCtr.Directory(".").Entries(ctx)
+		require.NoError(t, err)
+		require.Contains(t, generatedFiles, "dagger.json")
+		require.Contains(t, generatedFiles, "dagger/")
+
+		out, err = modCtr.WithExec([]string{"dagger", "client", "list", "--json"}).Stdout(ctx)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"Generator":"go","Directory":"dagger"}]`, out)
+
+		// the client is recorded as generated from the module's schema
+		out, err = modCtr.WithExec([]string{"dagger", "client", "status"}).Stdout(ctx)
+		require.NoError(t, err)
+		require.Regexp(t, `go\s+dagger\s+up-to-date\n`, out)
+	})
+
+	t.Run("init where a module already exists", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		_, err := c.Container().From(golangImage).
+			WithMountedFile(testCLIBinPath, daggerCliFile(t, c)).
+			WithWorkdir("/work").
+			WithEnvVariable("_EXPERIMENTAL_DAGGER_CLI_BIN", "/bin/dagger").
+			With(nonNestedDevEngine(c)).
+			With(daggerNonNestedExec("init", "--name=test")).
+			With(daggerNonNestedExec("client", "init", "--generator", "go")).
+			Stdout(ctx)
+
+		requireErrOut(t, err, "module already exists, use 'dagger client install' to add a client to it")
+	})
+}
+
 func (ClientGeneratorTest) TestHostCall(ctx context.Context, t *testctx.T) {
 	type testCase struct {
 		baseImage string
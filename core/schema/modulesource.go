@@ -2521,12 +2521,6 @@ func (s *moduleSourceSchema) moduleSourceWithClient(
 		Directory: args.OutputDir.String(),
 	}
 
-	for _, client := range src.ConfigClients {
-		if filepath.Clean(client.Directory) == filepath.Clean(moduleConfigClient.Directory) {
-			return nil, fmt.Errorf("a client is already generated in the %s directory", client.Directory)
-		}
-	}
-
 	// Verify that the generator can be loaded as a module and clean
 	// the generator path if it's a local path.
 	if !sdk.IsModuleSDKBuiltin(moduleConfigClient.Generator) {
@@ -2555,13 +2549,33 @@ func (s *moduleSourceSchema) moduleSourceWithClient(
 		}
 	}
 
-	src.ConfigClients = append(src.ConfigClients, moduleConfigClient)
+	// Installing a client with the same generator in the same directory again
+	// updates it in place, e.g. to change the version of the generator.
+	existing := slices.IndexFunc(src.ConfigClients, func(client *modules.ModuleConfigClient) bool {
+		return filepath.Clean(client.Directory) == filepath.Clean(moduleConfigClient.Directory)
+	})
+	switch {
+	case existing == -1:
+		src.ConfigClients = append(src.ConfigClients, moduleConfigClient)
+	case sameClientGenerator(src.ConfigClients[existing].Generator, moduleConfigClient.Generator):
+		src.ConfigClients[existing] = moduleConfigClient
+	default:
+		return nil, fmt.Errorf("a client is already generated in the %s directory", src.ConfigClients[existing].Directory)
+	}
 
 	src.Digest = src.CalcDigest().String()
 
 	return src, nil
 }
 
+// sameClientGenerator returns true if both generators refer to the same
+// generator, regardless of their version.
+func sameClientGenerator(a, b string) bool {
+	aSource, _, _ := strings.Cut(a, "@")
+	bSource, _, _ := strings.Cut(b, "@")
+	return aSource == bSource
+}
+
 func (s *moduleSourceSchema) moduleSourceWithUpdatedClients(
 	ctx context.Context,
 	src *core.ModuleSource,
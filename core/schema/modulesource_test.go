@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/modules"
+	"github.com/dagger/dagger/dagql"
 	"github.com/stretchr/testify/require"
 )
 
@@ -59,3 +61,41 @@ func TestModuleLocaLSource(t *testing.T) {
 		})
 	}
 }
+
+func TestModuleSourceWithClient(t *testing.T) {
+	schema := &moduleSourceSchema{}
+	ctx := context.Background()
+
+	type args = struct {
+		Generator dagql.String
+		OutputDir dagql.String
+	}
+
+	src := &core.ModuleSource{
+		Kind: core.ModuleSourceKindLocal,
+		Local: &core.LocalModuleSource{
+			ContextDirectoryPath: "/home/user/dagger-test-modules",
+		},
+	}
+
+	src, err := schema.moduleSourceWithClient(ctx, src, args{Generator: "go", OutputDir: "dagger"})
+	require.NoError(t, err)
+	require.Len(t, src.ConfigClients, 1)
+
+	// installing the same generator at the same path updates the client
+	updated, err := schema.moduleSourceWithClient(ctx, src, args{Generator: "go", OutputDir: "./dagger/"})
+	require.NoError(t, err)
+	require.Equal(t, []*modules.ModuleConfigClient{
+		{Generator: "go", Directory: "./dagger/"},
+	}, updated.ConfigClients)
+
+	// the original source is left untouched
+	require.Equal(t, "dagger", src.ConfigClients[0].Directory)
+
+	_, err = schema.moduleSourceWithClient(ctx, src, args{Generator: "python", OutputDir: "dagger"})
+	require.ErrorContains(t, err, "a client is already generated in the dagger directory")
+
+	other, err := schema.moduleSourceWithClient(ctx, src, args{Generator: "python", OutputDir: "other"})
+	require.NoError(t, err)
+	require.Len(t, other.ConfigClients, 2)
+}
@@ -117,6 +117,16 @@ func (d DynamicArrayOutput) NthValue(i int, enumID *call.ID) (AnyResult, error)
 	}, nil
 }
 
+func (d DynamicArrayOutput) Slice(from, to int) (Enumerable, error) {
+	if err := checkSlice(from, to, len(d.Values)); err != nil {
+		return nil, err
+	}
+	return DynamicArrayOutput{
+		Elem:   d.Elem,
+		Values: d.Values[from:to],
+	}, nil
+}
+
 func (d DynamicArrayOutput) MarshalJSON() ([]byte, error) {
 	return json.Marshal(d.Values)
 }
@@ -173,6 +183,20 @@ func (d DynamicResultArrayOutput) NthValue(i int, _ *call.ID) (AnyResult, error)
 	return d.Values[i-1], nil
 }
 
+func (d DynamicResultArrayOutput) nthResult(i int) (AnyResult, error) {
+	return d.NthValue(i, nil)
+}
+
+func (d DynamicResultArrayOutput) Slice(from, to int) (Enumerable, error) {
+	if err := checkSlice(from, to, len(d.Values)); err != nil {
+		return nil, err
+	}
+	return DynamicResultArrayOutput{
+		Elem:   d.Elem,
+		Values: d.Values[from:to],
+	}, nil
+}
+
 func (d DynamicResultArrayOutput) MarshalJSON() ([]byte, error) {
 	return json.Marshal(d.Values)
 }
@@ -353,3 +377,13 @@ func (d DynamicArrayInput) NthValue(i int, enumID *call.ID) (AnyResult, error) {
 		self:        t,
 	}, nil
 }
+
+func (d DynamicArrayInput) Slice(from, to int) (Enumerable, error) {
+	if err := checkSlice(from, to, len(d.Values)); err != nil {
+		return nil, err
+	}
+	return DynamicArrayInput{
+		Elem:   d.Elem,
+		Values: d.Values[from:to],
+	}, nil
+}
@@ -6,8 +6,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/opencontainers/go-digest"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -62,7 +64,9 @@ type ID struct {
 
 type View string
 
-// The ID of the object that the field selection will be evaluated against.
+// The ID of the object that the field selection will be evaluated against,
+// i.e. the parent of this ID in its call chain, without the last selection.
+// Calling Receiver repeatedly walks up the chain to the root.
 //
 // If nil, the root Query object is implied.
 func (id *ID) Receiver() *ID {
@@ -125,6 +129,12 @@ func (id *ID) Digest() digest.Digest {
 	return digest.Digest(id.pb.Digest)
 }
 
+// Equals reports whether the two IDs are the same, by comparing their digests.
+// Two nil IDs, i.e. the root, are equal.
+func (id *ID) Equals(other *ID) bool {
+	return id.Digest() == other.Digest()
+}
+
 func (id *ID) Inputs() ([]digest.Digest, error) {
 	seen := map[digest.Digest]struct{}{}
 	var inputs []digest.Digest
@@ -169,30 +179,42 @@ func (id *ID) Modules() []*Module {
 }
 
 func (id *ID) Path() string {
+	return id.path(0)
+}
+
+func (id *ID) DisplaySelf() string {
+	return id.displaySelf(0)
+}
+
+// path renders the chain of calls of the ID, abbreviating argument values
+// longer than valueLen runes, unless valueLen is 0.
+func (id *ID) path(valueLen int) string {
 	buf := new(strings.Builder)
 	if id.receiver != nil {
-		fmt.Fprintf(buf, "%s.", id.receiver.Path())
+		fmt.Fprintf(buf, "%s.", id.receiver.path(valueLen))
 	}
-	fmt.Fprint(buf, id.DisplaySelf())
+	fmt.Fprint(buf, id.displaySelf(valueLen))
 	return buf.String()
 }
 
-func (id *ID) DisplaySelf() string {
+func (id *ID) displaySelf(valueLen int) string {
 	buf := new(strings.Builder)
 	fmt.Fprintf(buf, "%s", id.pb.Field)
-	for ai, arg := range id.args {
+	var displayed int
+	for _, arg := range id.args {
 		if arg.isSensitive {
 			continue
 		}
-		if ai == 0 {
+		if displayed == 0 {
 			fmt.Fprintf(buf, "(")
 		} else {
 			fmt.Fprintf(buf, ", ")
 		}
-		fmt.Fprintf(buf, "%s: %s", arg.pb.Name, arg.value.Display())
-		if ai == len(id.args)-1 {
-			fmt.Fprintf(buf, ")")
-		}
+		fmt.Fprintf(buf, "%s: %s", arg.pb.Name, abbreviate(arg.value.Display(), valueLen))
+		displayed++
+	}
+	if displayed > 0 {
+		fmt.Fprintf(buf, ")")
 	}
 	if id.pb.Nth != 0 {
 		fmt.Fprintf(buf, "#%d", id.pb.Nth)
@@ -200,6 +222,51 @@ func (id *ID) DisplaySelf() string {
 	return buf.String()
 }
 
+// abbreviate truncates the string to n runes, ending with an ellipsis, unless
+// n is 0.
+func abbreviate(str string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(str) <= n {
+		return str
+	}
+	return string([]rune(str)[:n-1]) + "…"
+}
+
+// String renders the ID as the chain of calls that constructs it, e.g.
+// `container.from(address: "alpine").withExec(args: ["go","build"])`, omitting
+// the values of sensitive arguments.
+func (id *ID) String() string {
+	if id == nil {
+		return "<nil>"
+	}
+	return id.Path()
+}
+
+// DisplayString renders the ID like String, abbreviating argument values such
+// as nested IDs so that it fits in maxLen runes, e.g. for display in a
+// terminal. Field and argument names are never abbreviated, so the result may
+// still be longer than maxLen.
+func (id *ID) DisplayString(maxLen int) string {
+	full := id.String()
+	if id == nil || maxLen <= 0 || utf8.RuneCountInString(full) <= maxLen {
+		return full
+	}
+
+	var longest int
+	for cur := id; cur != nil; cur = cur.receiver {
+		for _, arg := range cur.args {
+			if !arg.isSensitive {
+				longest = max(longest, utf8.RuneCountInString(arg.value.Display()))
+			}
+		}
+	}
+
+	// find the longest abbreviation of values that fits
+	valueLen := sort.Search(longest, func(n int) bool {
+		return utf8.RuneCountInString(id.path(n+1)) > maxLen
+	})
+	return id.path(max(valueLen, 1))
+}
+
 func (id *ID) Display() string {
 	if id == nil {
 		return "<nil>"
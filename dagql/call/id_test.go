@@ -0,0 +1,36 @@
+package call
+
+import (
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"gotest.tools/v3/assert"
+)
+
+func TestIDEquals(t *testing.T) {
+	pointType := &ast.Type{NamedType: "Point", NonNull: true}
+	point := func(receiver *ID, x int64) *ID {
+		return receiver.Append(pointType, "point", "", nil, 0, "",
+			NewArgument("x", NewLiteralInt(x), false))
+	}
+
+	t.Run("root", func(t *testing.T) {
+		assert.Assert(t, New().Equals(New()))
+		assert.Assert(t, !New().Equals(point(New(), 1)))
+		assert.Assert(t, !point(New(), 1).Equals(New()))
+	})
+
+	t.Run("same call", func(t *testing.T) {
+		assert.Assert(t, point(New(), 1).Equals(point(New(), 1)))
+	})
+
+	t.Run("different args", func(t *testing.T) {
+		assert.Assert(t, !point(New(), 1).Equals(point(New(), 2)))
+	})
+
+	t.Run("different receivers", func(t *testing.T) {
+		receiver := point(New(), 2)
+		assert.Assert(t, !point(New(), 1).Equals(point(receiver, 1)))
+		assert.Assert(t, point(receiver, 1).Equals(point(point(New(), 2), 1)))
+	})
+}
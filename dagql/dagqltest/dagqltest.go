@@ -0,0 +1,70 @@
+// Package dagqltest provides utilities for testing dagql resolvers.
+package dagqltest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/engine/cache"
+)
+
+// Server is a dagql.Server for unit-testing resolvers, which executes queries
+// in-process and fails the test on errors.
+type Server struct {
+	*dagql.Server
+}
+
+// NewServer returns a Server with the given root object and a fresh cache.
+// Install types and fields on it as with any dagql.Server.
+func NewServer[T dagql.Typed](root T) *Server {
+	return &Server{
+		Server: dagql.NewServer(root, dagql.NewSessionCache(cache.NewCache[string, dagql.AnyResult]())),
+	}
+}
+
+// Execute executes the query with the given variables and returns its result
+// as decoded JSON, calling t.Fatal if it fails.
+func (s *Server) Execute(t testing.TB, query string, vars map[string]any) map[string]any {
+	t.Helper()
+	res, err := s.Query(context.Background(), query, vars)
+	if err != nil {
+		t.Fatalf("execute query: %s", err)
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("marshal result: %s", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal result: %s", err)
+	}
+	return decoded
+}
+
+// Fixture is a Server bound to a single test, whose cache is released when
+// the test completes.
+type Fixture struct {
+	*dagql.Server
+	t testing.TB
+}
+
+// NewFixture returns a Fixture for the test with the given root object. Like
+// any dagql.Server, it has the built-in scalars installed.
+func NewFixture[T dagql.Typed](t testing.TB, root T) *Fixture {
+	srv := NewServer(root)
+	t.Cleanup(func() {
+		if err := srv.Cache.ReleaseAndClose(context.Background()); err != nil {
+			t.Errorf("release cache: %s", err)
+		}
+	})
+	return &Fixture{Server: srv.Server, t: t}
+}
+
+// Execute executes the query and returns its result as decoded JSON, failing
+// the test if it fails.
+func (f *Fixture) Execute(query string) map[string]any {
+	f.t.Helper()
+	return (&Server{Server: f.Server}).Execute(f.t, query, nil)
+}
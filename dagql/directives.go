@@ -1,6 +1,9 @@
 package dagql
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/vektah/gqlparser/v2/ast"
 
 	"github.com/dagger/dagger/dagql/call"
@@ -70,6 +73,10 @@ var (
 	DirectiveLocationInputFieldDefinition = DirectiveLocations.Register("INPUT_FIELD_DEFINITION")
 )
 
+// defaultDeprecationReason is the reason given to elements deprecated without
+// one, as defined by the GraphQL spec.
+const defaultDeprecationReason = "No longer supported"
+
 func deprecated(reason string) *ast.Directive {
 	return &ast.Directive{
 		Name: "deprecated",
@@ -105,3 +112,83 @@ func internal() *ast.Directive {
 		Name: "internal",
 	}
 }
+
+// DirectiveHandler is called around the selection of a field that has the
+// directive it's registered for, either in the field's definition or in the
+// query, e.g. to implement an @auth directive.
+//
+// args are the arguments of the directive. The handler must call next to
+// continue the selection.
+type DirectiveHandler func(ctx context.Context, self AnyObjectResult, sel Selector, args map[string]any, next SelectFunc) (AnyResult, error)
+
+// AppliedDirective is a directive applied to a field, with its arguments
+// evaluated.
+type AppliedDirective struct {
+	Name string
+	Args map[string]any
+}
+
+// RegisterDirective installs the directive into the schema, and registers a
+// handler to be called around the selection of every field that has it.
+//
+// Handlers are called within all middlewares, for the directives of the
+// field's definition first, and then for those of the query.
+func (s *Server) RegisterDirective(directive DirectiveSpec, handler DirectiveHandler) {
+	s.InstallDirective(directive)
+	s.updateHandlers(func(h *handlerSet) {
+		h.directiveHandlers[directive.Name] = handler
+	})
+}
+
+// handledDirectives returns the directives that have handlers registered,
+// with their arguments evaluated.
+func (s *Server) handledDirectives(directives ast.DirectiveList, vars map[string]any) ([]AppliedDirective, error) {
+	handlers := s.handlers.Load().directiveHandlers
+
+	var applied []AppliedDirective
+	for _, directive := range directives {
+		if _, ok := handlers[directive.Name]; !ok {
+			continue
+		}
+		args := map[string]any{}
+		for _, arg := range directive.Arguments {
+			val, err := arg.Value.Value(vars)
+			if err != nil {
+				return nil, fmt.Errorf("@%s: %w", directive.Name, err)
+			}
+			args[arg.Name] = val
+		}
+		s.installLock.Lock()
+		spec := s.directives[directive.Name]
+		s.installLock.Unlock()
+		for _, argSpec := range spec.Args.Inputs(s.View) {
+			if _, ok := args[argSpec.Name]; !ok && argSpec.Default != nil {
+				args[argSpec.Name] = argSpec.Default.ToLiteral().ToInput()
+			}
+		}
+		applied = append(applied, AppliedDirective{
+			Name: directive.Name,
+			Args: args,
+		})
+	}
+	return applied, nil
+}
+
+// withDirectiveHandlers wraps the selection of a field with the handlers of
+// the directives applied to it.
+func (s *Server) withDirectiveHandlers(next SelectFunc, directives []AppliedDirective) SelectFunc {
+	handlers := s.handlers.Load().directiveHandlers
+
+	for i := len(directives) - 1; i >= 0; i-- {
+		directive, inner := directives[i], next
+		handler, ok := handlers[directive.Name]
+		if !ok {
+			// e.g. read back by SelectionsFromJSON on a server without it
+			continue
+		}
+		next = func(ctx context.Context, self AnyObjectResult, sel Selector) (AnyResult, error) {
+			return handler(ctx, self, sel, directive.Args, inner)
+		}
+	}
+	return next
+}
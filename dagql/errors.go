@@ -1,6 +1,99 @@
 package dagql
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"maps"
+	"runtime/debug"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Error codes reported in the `code` extension of errors returned by the
+// server.
+const (
+	ErrCodeNotFound          = "NOT_FOUND"
+	ErrCodePermissionDenied  = "PERMISSION_DENIED"
+	ErrCodeInvalidArgument   = "INVALID_ARGUMENT"
+	ErrCodeResourceExhausted = "RESOURCE_EXHAUSTED"
+	ErrCodeTimeout           = "TIMEOUT"
+	ErrCodeFieldTimeout      = "FIELD_TIMEOUT"
+	ErrCodeUnavailable       = "UNAVAILABLE"
+	ErrCodePanic             = "PANIC"
+	ErrCodeInternal          = "INTERNAL"
+)
+
+// Error is an error with a machine-readable code, so that clients can tell
+// kinds of errors apart without parsing messages.
+//
+// The code and details are reported in the `extensions` of the GraphQL error.
+type Error struct {
+	// Code identifies the kind of error, e.g. ErrCodeNotFound.
+	Code string
+	// Path is the path of the field that failed. If unset, it is set to the
+	// path of the field that returned the error.
+	Path []string
+	// Details is additional machine-readable data about the error.
+	Details map[string]any
+	// Err is the underlying error.
+	Err error
+}
+
+// NewError returns an error with the given code wrapping err.
+func NewError(code string, err error) *Error {
+	return &Error{
+		Code: code,
+		Err:  err,
+	}
+}
+
+// Errorf returns an error with the given code and formatted message.
+func Errorf(code string, format string, args ...any) *Error {
+	return NewError(code, fmt.Errorf(format, args...))
+}
+
+// WithDetail returns a copy of the error with the given detail set.
+func (err *Error) WithDetail(key string, value any) *Error {
+	cp := *err
+	cp.Details = maps.Clone(err.Details)
+	if cp.Details == nil {
+		cp.Details = map[string]any{}
+	}
+	cp.Details[key] = value
+	return &cp
+}
+
+func (err *Error) Error() string {
+	if err.Err == nil {
+		return err.Code
+	}
+	return err.Err.Error()
+}
+
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+var _ ExtendedError = (*Error)(nil)
+
+func (err *Error) Extensions() map[string]any {
+	ext := maps.Clone(err.Details)
+	if ext == nil {
+		ext = map[string]any{}
+	}
+	if err.Code != "" {
+		ext["code"] = err.Code
+	}
+	return ext
+}
+
+func (err *Error) astPath() ast.Path {
+	path := make(ast.Path, len(err.Path))
+	for i, name := range err.Path {
+		path[i] = ast.PathName(name)
+	}
+	return path
+}
 
 type PanicError struct {
 	Cause     any
@@ -10,9 +103,63 @@ type PanicError struct {
 }
 
 func (err PanicError) Error() string {
-	return fmt.Sprintf("panic while resolving %s.%s: %v\n\n%s",
-		err.Self.Type().Name(),
-		err.Selection.Alias,
-		err.Cause,
-		string(err.Stack))
+	self := err.Self.Type().Name()
+	if id := err.Self.ID(); id != nil {
+		self = id.DisplayString(maxIDDisplayLen)
+	}
+	return fmt.Sprintf("panic while resolving %s.%s: %v",
+		self,
+		err.Selection.Name(),
+		err.Cause)
+}
+
+var _ ExtendedError = PanicError{}
+
+// Extensions reports the stack trace of the panic along with its code.
+func (err PanicError) Extensions() map[string]any {
+	return map[string]any{
+		"code":  ErrCodePanic,
+		"stack": string(err.Stack),
+	}
+}
+
+// PanicFunc is called when a panic is recovered while resolving a query, with
+// the recovered value and the stack trace of the panic.
+type PanicFunc func(ctx context.Context, recovered any, stack []byte)
+
+// OnPanic registers a function to be called for every panic recovered while
+// resolving a query, e.g. for alerting. The panic is still returned to the
+// client as an error with the ErrCodePanic code.
+func (s *Server) OnPanic(fn PanicFunc) {
+	s.updateHandlers(func(h *handlerSet) {
+		h.panicHandlers = append(h.panicHandlers, fn)
+	})
+}
+
+// panicError returns the error for a panic recovered while resolving the
+// selection on the object, reporting it to the panic handlers. It must be
+// called from the deferred function that recovered it, so that the stack trace
+// is the panic's.
+func (s *Server) panicError(ctx context.Context, recovered any, self AnyResult, sel Selection) PanicError {
+	err := PanicError{
+		Cause:     recovered,
+		Self:      self,
+		Selection: sel,
+		Stack:     debug.Stack(),
+	}
+	for _, handler := range s.handlers.Load().panicHandlers {
+		handler(ctx, err.Cause, err.Stack)
+	}
+	return err
+}
+
+// catchPanic calls fn, returning any panic as an error rather than crashing
+// the goroutine.
+func (s *Server) catchPanic(ctx context.Context, self AnyResult, sel Selection, fn func() (any, error)) (res any, rerr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			rerr = s.panicError(ctx, r, self, sel)
+		}
+	}()
+	return fn()
 }
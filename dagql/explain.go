@@ -0,0 +1,193 @@
+package dagql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/opencontainers/go-digest"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// ExecutionPlan describes how a query would be executed.
+type ExecutionPlan struct {
+	// Complexity is the complexity of the query, as checked against the
+	// server's maximum.
+	Complexity int
+	// Depth is the maximum nesting depth of the query.
+	Depth int
+	// Fields are the fields selected on the root object.
+	Fields []*PlanNode
+}
+
+// PlanNode is a field selection in an ExecutionPlan.
+type PlanNode struct {
+	// Name is the key of the field in the response, i.e. its alias or name.
+	Name string
+	// Field is the name of the selected field.
+	Field string
+	// Type is the return type of the field.
+	Type string
+	// TypeCondition is the concrete type the selection applies to, if the
+	// field is selected on an abstract type.
+	TypeCondition string
+	// Digest is the digest of the field's ID, if it can be known without
+	// executing the query. It can't for fields selected on the elements of a
+	// list, or for fields with a custom cache key.
+	Digest digest.Digest
+	// Cached is true if a result is cached for the field's digest, in which
+	// case executing the field won't call its resolver.
+	Cached bool
+	// Fields are the sub-selections of the field.
+	Fields []*PlanNode
+}
+
+// Explain parses and validates a query and returns its execution plan,
+// without executing it.
+//
+// The query must have exactly one operation, and must not use variables.
+func (s *Server) Explain(ctx context.Context, query string) (ExecutionPlan, error) {
+	doc, err := s.parseQuery(query)
+	if err != nil {
+		return ExecutionPlan{}, err
+	}
+	if len(doc.Operations) != 1 {
+		return ExecutionPlan{}, fmt.Errorf("expected a single operation, got %d", len(doc.Operations))
+	}
+	op := doc.Operations[0]
+	if op.Operation != ast.Query {
+		return ExecutionPlan{}, fmt.Errorf("cannot explain %s operations", op.Operation)
+	}
+
+	gqlOp := &graphql.OperationContext{
+		RawQuery: query,
+		Doc:      doc,
+	}
+	sels, err := s.parseASTSelections(srvToContext(ctx, s), gqlOp, s.Root().Type(), op.SelectionSet)
+	if err != nil {
+		return ExecutionPlan{}, fmt.Errorf("parse selections: %w", err)
+	}
+	return ExecutionPlan{
+		Complexity: s.complexity(s.Root().ObjectType(), s.View, sels),
+		Depth:      selectionDepth(sels),
+		Fields:     s.planSelections(s.Root().ObjectType(), s.Root().ID(), true, sels),
+	}, nil
+}
+
+// DumpSelectionTree renders the selections as an indented tree, with one
+// field per line along with its alias, arguments, and type condition, for
+// debugging.
+func (s *Server) DumpSelectionTree(sels []Selection) string {
+	var b strings.Builder
+	dumpSelections(&b, sels, 0)
+	return b.String()
+}
+
+func dumpSelections(b *strings.Builder, sels []Selection, depth int) {
+	for _, sel := range sels {
+		b.WriteString(strings.Repeat("  ", depth))
+		if sel.Alias != "" && sel.Alias != sel.Selector.Field {
+			b.WriteString(sel.Alias)
+			b.WriteString(": ")
+		}
+		b.WriteString(sel.Selector.String())
+		if sel.TypeCondition != "" {
+			fmt.Fprintf(b, " (on %s)", sel.TypeCondition)
+		}
+		b.WriteString("\n")
+		dumpSelections(b, sel.Subselections, depth+1)
+	}
+}
+
+// fieldCacheSpecs is implemented by object types that can report the cache
+// spec of their fields.
+type fieldCacheSpecs interface {
+	cacheSpec(name string, view call.View) (CacheSpec, bool)
+}
+
+// planSelections returns the plan of the selections on an object of the given
+// type whose ID is receiver, if known.
+func (s *Server) planSelections(class ObjectType, receiver *call.ID, knownID bool, sels []Selection) []*PlanNode {
+	nodes := make([]*PlanNode, 0, len(sels))
+	for _, sel := range sels {
+		node := &PlanNode{
+			Name:          sel.Name(),
+			Field:         sel.Selector.Field,
+			TypeCondition: sel.TypeCondition,
+		}
+		nodes = append(nodes, node)
+		if sel.Selector.Field == typenameField {
+			node.Type = String("").Type().String()
+			continue
+		}
+
+		selClass := class
+		if sel.TypeCondition != "" {
+			selClass, _ = s.ObjectType(sel.TypeCondition)
+		}
+		if selClass == nil {
+			continue
+		}
+		spec, ok := selClass.FieldSpec(sel.Selector.Field, sel.Selector.View)
+		if !ok {
+			continue
+		}
+		retType := spec.Type.Type()
+		node.Type = retType.String()
+
+		id, idOK := planID(selClass, &spec, receiver, knownID, sel.Selector)
+		if idOK {
+			node.Digest = id.Digest()
+			node.Cached = s.Cache != nil && s.Cache.Has(string(node.Digest))
+		}
+
+		if sel.Subselections != nil {
+			childClass, _ := s.ObjectType(retType.Name())
+			// the IDs of list elements depend on the length of the list, which
+			// isn't known until it's resolved
+			knownChildID := idOK && (retType.Elem == nil || sel.Selector.Nth != 0)
+			node.Fields = s.planSelections(childClass, id, knownChildID, sel.Subselections)
+		}
+	}
+	return nodes
+}
+
+// planID returns the ID that selecting the field on an object with the given
+// ID would have, if it can be known without calling the field.
+func planID(class ObjectType, spec *FieldSpec, receiver *call.ID, knownID bool, sel Selector) (*call.ID, bool) {
+	if !knownID {
+		return nil, false
+	}
+	specs, ok := class.(fieldCacheSpecs)
+	if !ok {
+		return nil, false
+	}
+	if cacheSpec, ok := specs.cacheSpec(sel.Field, sel.View); !ok || cacheSpec.GetCacheConfig != nil {
+		// the field has a custom cache key, computed from its receiver
+		return nil, false
+	}
+	view := sel.View
+	if spec.ViewFilter == nil {
+		view = ""
+	}
+	var idArgs []*call.Argument
+	for _, argSpec := range spec.Args.Inputs(view) {
+		input, ok := sel.Arg(argSpec.Name)
+		if !ok {
+			continue
+		}
+		input, err := decodeLiteralInput(argSpec, input)
+		if err != nil {
+			return nil, false
+		}
+		idArgs = append(idArgs, call.NewArgument(argSpec.Name, input.ToLiteral(), argSpec.Sensitive))
+	}
+	astType := spec.Type.Type()
+	if sel.Nth != 0 {
+		astType = astType.Elem
+	}
+	return receiver.Append(astType, sel.Field, view, spec.Module, sel.Nth, "", idArgs...), true
+}
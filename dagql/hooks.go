@@ -0,0 +1,103 @@
+package dagql
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// Event is a point in the resolution of a query that hooks can be registered
+// for.
+type Event string
+
+const (
+	// OnResolveStart is emitted before a field is selected.
+	OnResolveStart Event = "resolveStart"
+	// OnResolveEnd is emitted after a field is selected, with its result or
+	// error.
+	OnResolveEnd Event = "resolveEnd"
+	// OnCacheHit is emitted when the result of a cacheable field is found in
+	// the cache.
+	OnCacheHit Event = "cacheHit"
+	// OnCacheMiss is emitted when a cacheable field has to be called.
+	OnCacheMiss Event = "cacheMiss"
+)
+
+// HookData describes the event a hook is called for.
+type HookData struct {
+	// Self is the object the field is selected on.
+	Self AnyObjectResult
+	// Selector is the field selection. It is only set for resolve events.
+	Selector Selector
+	// ID is the ID of the selection. It is only set for cache events.
+	ID *call.ID
+	// Result is the result of the selection. It is only set for OnResolveEnd.
+	Result AnyResult
+	// Err is the error of the selection. It is only set for OnResolveEnd.
+	Err error
+	// Duration is how long the selection took. It is only set for
+	// OnResolveEnd.
+	Duration time.Duration
+}
+
+// HookFunc is called for the events it is registered for.
+type HookFunc func(ctx context.Context, event Event, data HookData)
+
+// RegisterHook registers a function to be called for every occurrence of the
+// event, e.g. for observability tools.
+//
+// Hooks are called synchronously during resolution, in the order they are
+// registered, so they should return quickly.
+func (s *Server) RegisterHook(event Event, fn HookFunc) {
+	s.updateHandlers(func(h *handlerSet) {
+		h.hooks[event] = append(slices.Clip(h.hooks[event]), fn)
+	})
+}
+
+// emit calls the hooks registered for the event.
+func (s *Server) emit(ctx context.Context, event Event, data HookData) {
+	for _, hook := range s.handlers.Load().hooks[event] {
+		hook(ctx, event, data)
+	}
+}
+
+// ObjectHookFunc is called for an object in an ObjectHook. Returning an error
+// fails the selection that the object is part of.
+type ObjectHookFunc func(ctx context.Context, obj AnyObjectResult) error
+
+// ObjectHook is called at points in the lifecycle of the objects of a type,
+// e.g. for audit logging or quota enforcement.
+type ObjectHook struct {
+	// OnCreate is called whenever a value of the type is instantiated as an
+	// object to select fields on, e.g. when it's returned by a field or loaded
+	// from its ID.
+	OnCreate ObjectHookFunc
+	// OnSelect is called before a field is selected on an object of the type.
+	OnSelect ObjectHookFunc
+}
+
+// On registers a hook for the objects of the named type. Either function of
+// the hook may be nil.
+//
+// Hooks are called in the order they are registered, stopping at the first
+// error.
+func (s *Server) On(typeName string, hook ObjectHook) {
+	s.updateHandlers(func(h *handlerSet) {
+		h.objectHooks[typeName] = append(slices.Clip(h.objectHooks[typeName]), hook)
+	})
+}
+
+// runObjectHooks calls the given function of the hooks registered for the
+// type of the object.
+func (s *Server) runObjectHooks(ctx context.Context, obj AnyObjectResult, fn func(ObjectHook) ObjectHookFunc) error {
+	for _, hook := range s.handlers.Load().objectHooks[obj.Type().Name()] {
+		if hookFn := fn(hook); hookFn != nil {
+			if err := hookFn(ctx, obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package dagql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+var _ http.Handler = (*Server)(nil)
+
+// DefaultMaxRequestBodySize is the default maximum size in bytes of the body
+// of requests served by ServeHTTP.
+const DefaultMaxRequestBodySize = 1 << 20
+
+// SetMaxRequestBodySize sets the maximum size in bytes of the body of requests
+// served by ServeHTTP and SSEHandler, which defaults to
+// DefaultMaxRequestBodySize. Requests with larger bodies fail with 413 Request
+// Entity Too Large.
+//
+// A value of 0 disables the limit.
+func (s *Server) SetMaxRequestBodySize(n int64) {
+	s.maxRequestBodySize = n
+}
+
+// ServeHTTP serves GraphQL queries, so that the server can be used with
+// net/http directly. It supports GET requests with the query in the URL, and
+// POST requests with a JSON body.
+//
+// Use NewDefaultHandler for websockets, file uploads, persisted queries, and
+// query caching.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.checkShutdown(w) || !s.checkSchemaVersion(w, r) {
+		return
+	}
+	params, ok := s.readGraphQLParams(w, r)
+	if !ok {
+		return
+	}
+	status, res := s.execParams(r, params)
+	writeGraphQLResponse(w, status, res)
+}
+
+// readGraphQLParams reads the parameters of a GraphQL request, responding with
+// an error if they're invalid.
+func (s *Server) readGraphQLParams(w http.ResponseWriter, r *http.Request) (*graphql.RawParams, bool) {
+	if s.maxRequestBodySize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxRequestBodySize)
+	}
+	params, status, err := graphqlParams(r)
+	if err != nil {
+		if status == http.StatusMethodNotAllowed {
+			w.Header().Set("Allow", "GET, POST")
+		}
+		writeGraphQLResponse(w, status, &graphql.Response{
+			Errors: gqlErrs(NewError(ErrCodeInvalidArgument, err)),
+		})
+		return nil, false
+	}
+	return params, true
+}
+
+// requestContext returns the context of the request, with the request's
+// metadata unless it has already been set.
+func requestContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if _, ok := CurrentRequestMetadata(ctx); !ok {
+		md := requestMetadataFromHeaders(r.Header)
+		md.RemoteAddr = r.RemoteAddr
+		ctx = ContextWithRequestMetadata(ctx, md)
+	}
+	return ctx
+}
+
+// execParams executes the operation of a GraphQL request, returning the
+// response along with the HTTP status to respond with.
+func (s *Server) execParams(r *http.Request, params *graphql.RawParams) (int, *graphql.Response) {
+	gqlOp := &graphql.OperationContext{
+		RawQuery:      params.Query,
+		OperationName: params.OperationName,
+		Variables:     params.Variables,
+		Headers:       r.Header,
+	}
+	status := http.StatusOK
+	res := s.execHandler(func(ctx context.Context) *graphql.Response {
+		results, execErr := s.ExecOp(ctx, gqlOp)
+		if execErr != nil && results == nil {
+			return &graphql.Response{
+				Errors: gqlErrs(execErr),
+			}
+		}
+
+		data, err := json.Marshal(results)
+		if err != nil {
+			status = http.StatusInternalServerError
+			return &graphql.Response{
+				Errors: gqlErrs(NewError(ErrCodeInternal, fmt.Errorf("marshal: %w", err))),
+			}
+		}
+		return &graphql.Response{
+			Data:   json.RawMessage(data),
+			Errors: gqlErrs(execErr),
+		}
+	})(graphql.WithOperationContext(requestContext(r), gqlOp))
+	return status, res
+}
+
+// graphqlParams reads the parameters of a GraphQL request, returning the HTTP
+// status to respond with if they're invalid.
+func graphqlParams(r *http.Request) (*graphql.RawParams, int, error) {
+	params := &graphql.RawParams{}
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		params.Query = query.Get("query")
+		params.OperationName = query.Get("operationName")
+		if vars := query.Get("variables"); vars != "" {
+			if err := decodeJSON([]byte(vars), &params.Variables); err != nil {
+				return nil, http.StatusBadRequest, fmt.Errorf("decode variables: %w", err)
+			}
+		}
+	case http.MethodPost:
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			return nil, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type %q", mediaType)
+		}
+		var body bytes.Buffer
+		if _, err := body.ReadFrom(r.Body); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds the maximum of %d bytes", maxErr.Limit)
+			}
+			return nil, http.StatusBadRequest, fmt.Errorf("read body: %w", err)
+		}
+		if err := decodeJSON(body.Bytes(), params); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("decode body: %w", err)
+		}
+	default:
+		return nil, http.StatusMethodNotAllowed, fmt.Errorf("unsupported method %s", r.Method)
+	}
+	if params.Query == "" {
+		return nil, http.StatusBadRequest, errors.New("no query")
+	}
+	return params, http.StatusOK, nil
+}
+
+// decodeJSON decodes JSON keeping numbers as json.Number, so that integer
+// variables remain integers.
+func decodeJSON(data []byte, dest any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(dest)
+}
+
+func writeGraphQLResponse(w http.ResponseWriter, status int, res *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,412 @@
+package dagql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Stream configures the incremental delivery of the elements of a list field,
+// as requested with the @stream directive.
+type Stream struct {
+	// InitialCount is the number of elements included in the initial response.
+	// The rest are delivered in subsequent payloads.
+	InitialCount int
+	// Label identifies the payloads of the stream, if set.
+	Label string
+}
+
+var streamDirective = DirectiveSpec{
+	Name: "stream",
+	Description: FormatDescription(
+		`The @stream directive may be provided for list fields, and allows the
+		elements of the list past the initialCount to be delivered after the
+		initial response, as described by the GraphQL incremental delivery
+		specification.`),
+	Args: NewInputSpecs(
+		InputSpec{
+			Name:        "initialCount",
+			Description: FormatDescription(`The number of elements to include in the initial response.`),
+			Type:        Int(0),
+			Default:     Int(0),
+		},
+		InputSpec{
+			Name:        "label",
+			Description: FormatDescription(`Identifies the payloads of the stream.`),
+			Type:        Optional[String]{},
+		},
+		InputSpec{
+			Name:        "if",
+			Description: FormatDescription(`Streamed when true.`),
+			Type:        Boolean(false),
+			Default:     Boolean(true),
+		},
+	),
+	Locations: []DirectiveLocation{
+		DirectiveLocationField,
+	},
+}
+
+// Defer configures the incremental delivery of the fields of a fragment, as
+// requested with the @defer directive.
+type Defer struct {
+	// Label identifies the payload of the fragment, if set.
+	Label string
+}
+
+var deferDirective = DirectiveSpec{
+	Name: "defer",
+	Description: FormatDescription(
+		`The @defer directive may be provided for fragment spreads and inline
+		fragments, and allows their fields to be delivered after the initial
+		response, as described by the GraphQL incremental delivery
+		specification.`),
+	Args: NewInputSpecs(
+		InputSpec{
+			Name:        "label",
+			Description: FormatDescription(`Identifies the payload of the fragment.`),
+			Type:        Optional[String]{},
+		},
+		InputSpec{
+			Name:        "if",
+			Description: FormatDescription(`Deferred when true.`),
+			Type:        Boolean(false),
+			Default:     Boolean(true),
+		},
+	),
+	Locations: []DirectiveLocation{
+		DirectiveLocationFragmentSpread,
+		DirectiveLocationInlineFragment,
+	},
+}
+
+// parseStream parses the @stream directive of a field, returning nil if it's
+// absent or disabled.
+func parseStream(field *ast.Field, resType *ast.Type, vars map[string]any) (*Stream, error) {
+	directive := field.Directives.ForName("stream")
+	if directive == nil {
+		return nil, nil
+	}
+	if resType.Elem == nil {
+		return nil, fmt.Errorf("@stream: field %q is not a list", field.Name)
+	}
+	args, err := incrementalArgs(directive, vars)
+	if err != nil || args == nil {
+		return nil, err
+	}
+	stream := &Stream{
+		Label: args.label,
+	}
+	if val, ok := args.vals["initialCount"]; ok {
+		count, err := Int(0).Decoder().DecodeInput(val)
+		if err != nil {
+			return nil, fmt.Errorf("@stream: initialCount: %w", err)
+		}
+		stream.InitialCount = int(count.(Int))
+		if stream.InitialCount < 0 {
+			return nil, fmt.Errorf("@stream: initialCount must be non-negative")
+		}
+	}
+	return stream, nil
+}
+
+// parseDefer parses the @defer directive of a fragment, returning nil if it's
+// absent or disabled.
+func parseDefer(directives ast.DirectiveList, vars map[string]any) (*Defer, error) {
+	directive := directives.ForName("defer")
+	if directive == nil {
+		return nil, nil
+	}
+	args, err := incrementalArgs(directive, vars)
+	if err != nil || args == nil {
+		return nil, err
+	}
+	return &Defer{
+		Label: args.label,
+	}, nil
+}
+
+// deferFragment marks the selections of a fragment as deferred, if the
+// fragment has the @defer directive. Selections of nested fragments that are
+// deferred on their own are left as is.
+func deferFragment(sels []Selection, directives ast.DirectiveList, vars map[string]any) error {
+	fragment, err := parseDefer(directives, vars)
+	if err != nil || fragment == nil {
+		return err
+	}
+	for i := range sels {
+		if sels[i].Defer == nil {
+			sels[i].Defer = fragment
+		}
+	}
+	return nil
+}
+
+// incrementalDirectiveArgs are the arguments of an incremental delivery
+// directive.
+type incrementalDirectiveArgs struct {
+	label string
+	vals  map[string]any
+}
+
+// incrementalArgs evaluates the arguments of an incremental delivery
+// directive, returning nil if it's disabled with its if argument.
+func incrementalArgs(directive *ast.Directive, vars map[string]any) (*incrementalDirectiveArgs, error) {
+	args := &incrementalDirectiveArgs{
+		vals: map[string]any{},
+	}
+	for _, arg := range directive.Arguments {
+		val, err := arg.Value.Value(vars)
+		if err != nil {
+			return nil, fmt.Errorf("@%s: %w", directive.Name, err)
+		}
+		switch arg.Name {
+		case "if":
+			cond, ok := val.(bool)
+			if !ok {
+				return nil, fmt.Errorf("@%s: expected Boolean, got %T", directive.Name, val)
+			}
+			if !cond {
+				return nil, nil
+			}
+		case "label":
+			if val != nil {
+				label, ok := val.(string)
+				if !ok {
+					return nil, fmt.Errorf("@%s: label: expected String, got %T", directive.Name, val)
+				}
+				args.label = label
+			}
+		default:
+			args.vals[arg.Name] = val
+		}
+	}
+	return args, nil
+}
+
+// incrementalDelivery collects the payloads of a query that are delivered
+// after its initial response, i.e. the elements of lists selected with
+// @stream and the fields of fragments selected with @defer.
+//
+// The payloads are sent as gqlgen responses, to be framed by its
+// multipart/mixed transport.
+type incrementalDelivery struct {
+	// pending is the number of payloads that are yet to be delivered.
+	pending  atomic.Int64
+	payloads chan *graphql.Response
+
+	// stopped is closed once no more payloads will be delivered, e.g. because
+	// they all have been, or because the initial response failed.
+	stopped   chan struct{}
+	stopOnce  sync.Once
+	onStopped []func()
+}
+
+func newIncrementalDelivery() *incrementalDelivery {
+	return &incrementalDelivery{
+		payloads: make(chan *graphql.Response),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// acceptsIncrementalDelivery returns true if the client of the operation can
+// receive payloads after the initial response.
+func acceptsIncrementalDelivery(gqlOp *graphql.OperationContext) bool {
+	return strings.Contains(gqlOp.Headers.Get("Accept"), "multipart/mixed")
+}
+
+// expect registers the given number of payloads to be sent. It must be called
+// before the payload that the new ones are nested in, if any, is sent, so that
+// the delivery isn't considered done in between.
+func (d *incrementalDelivery) expect(n int) {
+	d.pending.Add(int64(n))
+}
+
+// send sends an expected payload, blocking until it's delivered or the
+// delivery is stopped.
+func (d *incrementalDelivery) send(resp *graphql.Response) {
+	select {
+	case d.payloads <- resp:
+	case <-d.stopped:
+	}
+}
+
+// hasNext returns true if payloads are yet to be delivered.
+func (d *incrementalDelivery) hasNext() bool {
+	select {
+	case <-d.stopped:
+		return false
+	default:
+		return d.pending.Load() > 0
+	}
+}
+
+// afterStop registers a function to be called once the delivery is stopped.
+// It must be called before the initial response is returned.
+func (d *incrementalDelivery) afterStop(fn func()) {
+	d.onStopped = append(d.onStopped, fn)
+}
+
+// stop stops the delivery of any pending payloads.
+func (d *incrementalDelivery) stop() {
+	d.stopOnce.Do(func() {
+		close(d.stopped)
+		for _, fn := range d.onStopped {
+			fn()
+		}
+	})
+}
+
+// next waits for the next payload, returning nil once all of them have been
+// delivered or the context is done.
+func (d *incrementalDelivery) next(ctx context.Context) *graphql.Response {
+	if !d.hasNext() {
+		d.stop()
+		return nil
+	}
+	select {
+	case resp := <-d.payloads:
+		hasNext := d.pending.Add(-1) > 0
+		if !hasNext {
+			d.stop()
+		}
+		resp.HasNext = &hasNext
+		return resp
+	case <-ctx.Done():
+		d.stop()
+		return nil
+	}
+}
+
+type incrementalDeliveryCtx struct{}
+
+func incrementalDeliveryToContext(ctx context.Context, d *incrementalDelivery) context.Context {
+	return context.WithValue(ctx, incrementalDeliveryCtx{}, d)
+}
+
+func incrementalDeliveryFromContext(ctx context.Context) *incrementalDelivery {
+	d, _ := ctx.Value(incrementalDeliveryCtx{}).(*incrementalDelivery)
+	return d
+}
+
+type responsePathCtx struct{}
+
+// appendResponsePath returns a context with the given element appended to the
+// response path of the value being resolved. It's only tracked for queries
+// with incremental delivery, whose payloads must point to where they belong
+// in the response.
+func appendResponsePath(ctx context.Context, elem ast.PathElement) context.Context {
+	if incrementalDeliveryFromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, responsePathCtx{}, append(slices.Clip(responsePathFromContext(ctx)), elem))
+}
+
+func responsePathFromContext(ctx context.Context) ast.Path {
+	path, _ := ctx.Value(responsePathCtx{}).(ast.Path)
+	return path
+}
+
+// streamElements resolves the elements of an enumerable value from the given
+// index onward in the background, sending each as a payload of the delivery.
+// It returns false if the server is shutting down, in which case nothing is
+// started and the elements must be resolved right away instead.
+//
+// gqlgen's responses can't carry the items of a stream payload, so each
+// element is sent as data at its index in the list instead, which clients
+// merge into the response the same way.
+func (s *Server) streamElements(ctx context.Context, d *incrementalDelivery, val AnyResult, sel Selection, from int) bool {
+	if !s.shutdown.join() {
+		return false
+	}
+	enum := val.Unwrap().(Enumerable)
+	path := responsePathFromContext(ctx)
+	d.expect(enum.Len() - from)
+	go func() {
+		defer s.shutdown.end()
+		for nth := from + 1; nth <= enum.Len(); nth++ {
+			fe := &fieldErrors{}
+			elemCtx := fieldErrorsToContext(appendResponsePath(ctx, ast.PathIndex(nth-1)), fe)
+			res, err := s.catchPanic(elemCtx, val, sel, func() (any, error) {
+				return s.resolveNth(elemCtx, val, sel, nth)
+			})
+			d.send(incrementalPayload(ctx, sel.Stream.Label, append(slices.Clip(path), ast.PathIndex(nth-1)), res, err, fe))
+		}
+	}()
+	return true
+}
+
+// deferSelections resolves the deferred selections on the object in the
+// background, sending the fields of each deferred fragment as a payload of the
+// delivery, and returns the selections to resolve right away. Once the server
+// is shutting down, fragments are no longer deferred, and are returned along
+// with the rest.
+func (s *Server) deferSelections(ctx context.Context, d *incrementalDelivery, self AnyObjectResult, sels []Selection) []Selection {
+	if !slices.ContainsFunc(sels, func(sel Selection) bool {
+		return sel.Defer != nil
+	}) {
+		return sels
+	}
+
+	var immediate []Selection
+	var fragments []*Defer
+	deferred := map[*Defer][]Selection{}
+	for _, sel := range sels {
+		if sel.Defer == nil {
+			immediate = append(immediate, sel)
+			continue
+		}
+		if _, ok := deferred[sel.Defer]; !ok {
+			fragments = append(fragments, sel.Defer)
+		}
+		fragment := sel.Defer
+		sel.Defer = nil
+		deferred[fragment] = append(deferred[fragment], sel)
+	}
+
+	path := responsePathFromContext(ctx)
+	for _, fragment := range fragments {
+		if !s.shutdown.join() {
+			immediate = append(immediate, deferred[fragment]...)
+			continue
+		}
+		d.expect(1)
+		go func() {
+			defer s.shutdown.end()
+			fe := &fieldErrors{}
+			fragmentCtx := fieldErrorsToContext(ctx, fe)
+			res, err := s.catchPanic(fragmentCtx, self, deferred[fragment][0], func() (any, error) {
+				return s.Resolve(fragmentCtx, self, deferred[fragment]...)
+			})
+			d.send(incrementalPayload(ctx, fragment.Label, path, res, err, fe))
+		}()
+	}
+	return immediate
+}
+
+// incrementalPayload returns the payload delivering the result of resolving
+// the value at the given path, with the trace ID of the request in its errors.
+func incrementalPayload(ctx context.Context, label string, path ast.Path, res any, err error, fe *fieldErrors) *graphql.Response {
+	resp := &graphql.Response{
+		Label: label,
+		Path:  path,
+		Data:  json.RawMessage("null"),
+	}
+	if err != nil {
+		resp.Errors = gqlErrs(err)
+	} else if data, err := json.Marshal(res); err != nil {
+		resp.Errors = gqlErrs(NewError(ErrCodeInternal, fmt.Errorf("marshal: %w", err)))
+	} else {
+		resp.Data = data
+	}
+	resp.Errors = append(resp.Errors, fe.list()...)
+	setTraceID(resp, traceIDFromContext(ctx))
+	return resp
+}
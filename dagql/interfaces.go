@@ -0,0 +1,179 @@
+package dagql
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// typenameField is the meta field that selects the concrete type name of an
+// object, which is how clients tell apart the results of abstract fields.
+const typenameField = "__typename"
+
+// InstallInterface installs the interface type T into the schema. Object types
+// are declared as implementations of it with ImplementsInterface.
+//
+// If T is Definitive, its definition is used as-is. Otherwise, the fields of
+// the interface are the fields shared by all of its implementations.
+func InstallInterface[T Typed](srv *Server) {
+	var iface T
+	srv.installLock.Lock()
+	defer srv.installLock.Unlock()
+	srv.interfaces[iface.Type().Name()] = iface
+	srv.invalidateSchemaCache()
+}
+
+// ImplementsInterface declares that the object type Impl implements the
+// interface type Iface.
+func ImplementsInterface[Iface, Impl Typed](srv *Server) {
+	var iface Iface
+	var impl Impl
+	ifaceName := iface.Type().Name()
+	implName := impl.Type().Name()
+	srv.installLock.Lock()
+	defer srv.installLock.Unlock()
+	if slices.Contains(srv.implements[implName], ifaceName) {
+		return
+	}
+	srv.implements[implName] = append(srv.implements[implName], ifaceName)
+	srv.invalidateSchemaCache()
+}
+
+// isAbstractType returns true if the named type is resolved to a concrete
+// object type at runtime.
+func (s *Server) isAbstractType(typeName string) bool {
+	s.installLock.Lock()
+	defer s.installLock.Unlock()
+	if _, ok := s.interfaces[typeName]; ok {
+		return true
+	}
+	_, ok := s.unions[typeName]
+	return ok
+}
+
+// possibleTypes returns the names of the installed object types that may be
+// returned for the named abstract type, in stable order.
+func (s *Server) possibleTypes(typeName string) []string {
+	s.installLock.Lock()
+	defer s.installLock.Unlock()
+	var names []string
+	if u, ok := s.unions[typeName]; ok {
+		names = slices.Clone(u.members)
+	} else {
+		names = s.implementations(typeName)
+	}
+	return slices.DeleteFunc(names, func(name string) bool {
+		_, ok := s.objects[name]
+		return !ok
+	})
+}
+
+// satisfiesType returns true if an object of the named type may be selected
+// by a fragment with the given type condition.
+func (s *Server) satisfiesType(typeName, cond string) bool {
+	if cond == "" || cond == typeName {
+		return true
+	}
+	s.installLock.Lock()
+	defer s.installLock.Unlock()
+	if _, ok := s.interfaces[cond]; ok {
+		return slices.Contains(s.implements[typeName], cond)
+	}
+	if _, ok := s.unions[cond]; ok {
+		return s.isUnionMember(typeName, cond)
+	}
+	// abstract types installed as raw type definitions aren't tracked, so only
+	// rule out conditions on other object types
+	_, isObject := s.objects[cond]
+	return !isObject
+}
+
+// interfaceDefinitions adds the definitions of all installed interfaces to the
+// schema, and links each object type to the interfaces it implements.
+func (s *Server) interfaceDefinitions(schema *ast.Schema, view call.View) {
+	for name, iface := range s.interfaces {
+		var def *ast.Definition
+		if isType, ok := iface.(Definitive); ok {
+			def = isType.TypeDefinition(view)
+		} else {
+			def = &ast.Definition{
+				Kind:   ast.Interface,
+				Name:   name,
+				Fields: sharedFields(schema, s.implementations(name)),
+			}
+		}
+		if isType, ok := iface.(Descriptive); ok {
+			def.Description = isType.TypeDescription()
+		}
+		schema.AddTypes(def)
+	}
+	for name, ifaces := range s.implements {
+		objDef, ok := schema.Types[name]
+		if !ok || objDef.Kind != ast.Object {
+			continue
+		}
+		for _, ifaceName := range ifaces {
+			ifaceDef, ok := schema.Types[ifaceName]
+			if !ok {
+				continue
+			}
+			objDef.Interfaces = append(objDef.Interfaces, ifaceName)
+			schema.AddPossibleType(ifaceName, objDef)
+			schema.AddImplements(name, ifaceDef)
+		}
+		sort.Strings(objDef.Interfaces)
+	}
+}
+
+// implementations returns the names of the object types implementing the
+// named interface.
+func (s *Server) implementations(ifaceName string) []string {
+	var names []string
+	for name, ifaces := range s.implements {
+		if slices.Contains(ifaces, ifaceName) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sharedFields returns the fields, with identical types, that are defined by
+// all of the named object types.
+func sharedFields(schema *ast.Schema, typeNames []string) ast.FieldList {
+	var fields ast.FieldList
+	var seen bool
+	for _, name := range typeNames {
+		def, ok := schema.Types[name]
+		if !ok {
+			continue
+		}
+		if !seen {
+			fields = slices.Clone(def.Fields)
+			seen = true
+			continue
+		}
+		fields = slices.DeleteFunc(fields, func(field *ast.FieldDefinition) bool {
+			other := def.Fields.ForName(field.Name)
+			return other == nil || other.Type.String() != field.Type.String()
+		})
+	}
+	return fields
+}
+
+// applicableSelections returns the selections that apply to the given object,
+// discarding those that are conditional on a different concrete type.
+func applicableSelections(self AnyObjectResult, sels []Selection) []Selection {
+	if !slices.ContainsFunc(sels, func(sel Selection) bool {
+		return sel.TypeCondition != ""
+	}) {
+		return sels
+	}
+	typeName := self.Type().Name()
+	return slices.DeleteFunc(slices.Clone(sels), func(sel Selection) bool {
+		return sel.TypeCondition != "" && sel.TypeCondition != typeName
+	})
+}
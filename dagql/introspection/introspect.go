@@ -0,0 +1,135 @@
+package introspection
+
+import (
+	"github.com/dagger/dagger/dagql"
+)
+
+// Introspect returns the response to the introspection Query for the server's
+// schema, as seen from its View, without executing the query.
+//
+// It serves Go tooling such as code generators, which would otherwise send the
+// query to the server and decode the response.
+func Introspect(srv *dagql.Server) *Response {
+	schema := WrapSchema(srv.Schema())
+	res := &ResponseSchema{
+		QueryType: ResponseNamedType{Name: *schema.QueryType().Name()},
+		Types:     ResponseSchemaTypes{},
+	}
+	if mutation := schema.MutationType(); mutation != nil {
+		res.MutationType = &ResponseNamedType{Name: *mutation.Name()}
+	}
+	if subscription := schema.SubscriptionType(); subscription != nil {
+		res.SubscriptionType = &ResponseNamedType{Name: *subscription.Name()}
+	}
+	for _, t := range schema.Types() {
+		res.Types = append(res.Types, responseType(t))
+	}
+	directives := []*ResponseDirectiveDefinition{}
+	for _, d := range schema.Directives() {
+		directives = append(directives, &ResponseDirectiveDefinition{
+			Name:        d.Name,
+			Description: d.Description(),
+			Locations:   d.Locations,
+			Args:        responseInputValues(d.Args(true)),
+		})
+	}
+	res.Directives = directives
+	return &Response{
+		Schema:        res,
+		SchemaVersion: string(srv.View),
+	}
+}
+
+func responseType(t *Type) *ResponseType {
+	res := &ResponseType{
+		Kind:        ResponseTypeKind(t.Kind()),
+		Name:        *t.Name(),
+		Description: t.Description(),
+		InputFields: responseInputValues(t.InputFields(true)),
+		Directives:  responseDirectives(t.Directives()),
+	}
+	for _, f := range t.Fields(true) {
+		res.Fields = append(res.Fields, &ResponseField{
+			Name:              f.Name,
+			Description:       f.Description(),
+			TypeRef:           responseTypeRef(f.Type_),
+			Args:              responseInputValues(f.Args(true)),
+			IsDeprecated:      f.IsDeprecated(),
+			DeprecationReason: deref(f.DeprecationReason()),
+			Directives:        responseDirectives(f.Directives()),
+		})
+	}
+	for _, val := range t.EnumValues(true) {
+		res.EnumValues = append(res.EnumValues, ResponseEnumValue{
+			Name:              val.Name,
+			Description:       val.Description(),
+			IsDeprecated:      val.IsDeprecated(),
+			DeprecationReason: deref(val.DeprecationReason()),
+			Directives:        responseDirectives(val.Directives()),
+		})
+	}
+	for _, iface := range t.Interfaces() {
+		res.Interfaces = append(res.Interfaces, &ResponseType{
+			Kind: ResponseTypeKind(iface.Kind()),
+			Name: *iface.Name(),
+		})
+	}
+	for _, possible := range t.PossibleTypes() {
+		res.PossibleTypes = append(res.PossibleTypes, &ResponseType{
+			Kind: ResponseTypeKind(possible.Kind()),
+			Name: *possible.Name(),
+		})
+	}
+	return res
+}
+
+func responseTypeRef(t *Type) *ResponseTypeRef {
+	if t == nil {
+		return nil
+	}
+	return &ResponseTypeRef{
+		Kind:   ResponseTypeKind(t.Kind()),
+		Name:   deref(t.Name()),
+		OfType: responseTypeRef(t.OfType()),
+	}
+}
+
+func responseInputValues(vals []*InputValue) ResponseInputValues {
+	res := ResponseInputValues{}
+	for _, val := range vals {
+		res = append(res, ResponseInputValue{
+			Name:              val.Name,
+			Description:       val.Description(),
+			DefaultValue:      val.DefaultValue,
+			TypeRef:           responseTypeRef(val.Type_),
+			IsDeprecated:      val.IsDeprecated(),
+			DeprecationReason: deref(val.DeprecationReason()),
+			Directives:        responseDirectives(val.Directives()),
+		})
+	}
+	return res
+}
+
+func responseDirectives(apps []*DirectiveApplication) []ResponseDirective {
+	res := []ResponseDirective{}
+	for _, app := range apps {
+		args := []*DirectiveArg{}
+		for _, arg := range app.Args {
+			var val *string
+			if arg.Value != nil {
+				s := arg.Value.String()
+				val = &s
+			}
+			args = append(args, &DirectiveArg{Name: arg.Name, Value: val})
+		}
+		res = append(res, ResponseDirective{Name: app.Name, Args: args})
+	}
+	return res
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
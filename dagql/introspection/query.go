@@ -21,6 +21,15 @@ type ResponseSchema struct {
 	Directives any `json:"directives"`
 }
 
+// ResponseDirectiveDefinition is a directive defined by the schema, as listed
+// in the directives of ResponseSchema.
+type ResponseDirectiveDefinition struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Locations   []string            `json:"locations"`
+	Args        ResponseInputValues `json:"args"`
+}
+
 type ResponseNamedType struct {
 	Name string `json:"name"`
 }
@@ -0,0 +1,323 @@
+package dagql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/engine/slog"
+)
+
+// listSizeArgs are the argument names that are taken to bound the size of the
+// list returned by a field when computing query complexity.
+var listSizeArgs = []string{"first", "limit"}
+
+// SetMaxComplexity sets the maximum complexity of a query accepted by the
+// server. Queries exceeding the limit are rejected before any resolver runs.
+//
+// Each selected field counts as 1 plus the complexity of its sub-selections.
+// For fields returning lists, the total is multiplied by the value of a
+// `first` or `limit` argument, if present.
+//
+// A value of 0 (the default) disables the limit.
+func (s *Server) SetMaxComplexity(n int) {
+	s.maxComplexity = n
+}
+
+// SetMaxDepth sets the maximum nesting depth of a query accepted by the
+// server. Queries exceeding the limit are rejected before any resolver runs.
+//
+// Top-level fields are at depth 1, their sub-selections at depth 2, and so on.
+//
+// A value of 0 (the default) disables the limit.
+func (s *Server) SetMaxDepth(n int) {
+	s.maxDepth = n
+}
+
+// SetMaxConcurrency sets the maximum number of goroutines used to resolve
+// sibling selections in parallel while executing a single request. When the
+// limit is reached, further selections are resolved sequentially instead.
+//
+// The limit applies per request, so independent clients don't contend for it.
+//
+// A value of 0 (the default) disables the limit.
+func (s *Server) SetMaxConcurrency(n int) {
+	s.maxConcurrency = n
+}
+
+// QueryTimeoutHeader is the request header with which a client may set the
+// timeout of its query, e.g. "30s". It can't exceed the server's timeout, if
+// any.
+const QueryTimeoutHeader = "X-Dagger-Timeout"
+
+// errQueryTimeout is the cause of the cancellation of a query that exceeds
+// its timeout.
+var errQueryTimeout = errors.New("query timeout exceeded")
+
+// SetQueryTimeout sets the maximum duration of the execution of a query.
+// Queries exceeding it have their context canceled, and fail with
+// ErrCodeTimeout without waiting for resolvers that ignore the cancellation.
+//
+// A value of 0 (the default) disables the limit, but clients may still set
+// one for their own queries with the QueryTimeoutHeader.
+func (s *Server) SetQueryTimeout(d time.Duration) {
+	s.queryTimeout = d
+
+	s.installLock.Lock()
+	defer s.installLock.Unlock()
+	for _, objType := range s.objects {
+		for _, spec := range objType.FieldSpecs(s.View) {
+			s.checkFieldTimeout(objType.TypeName(), &spec)
+		}
+	}
+}
+
+// errFieldTimeout is the cause of the cancellation of a field selection that
+// exceeds its timeout.
+var errFieldTimeout = errors.New("field timeout exceeded")
+
+// checkFieldTimeout warns if the timeout of the field isn't shorter than the
+// query timeout, which would make it pointless.
+func (s *Server) checkFieldTimeout(typeName string, spec *FieldSpec) {
+	if spec.timeout > 0 && s.queryTimeout > 0 && spec.timeout >= s.queryTimeout {
+		slog.Warn("field timeout is not shorter than the query timeout",
+			"type", typeName,
+			"field", spec.Name,
+			"timeout", spec.timeout,
+			"queryTimeout", s.queryTimeout)
+	}
+}
+
+// selectFieldWithTimeout selects the given field on the object, failing once
+// the timeout of the field, if any, is exceeded.
+func (s *Server) selectFieldWithTimeout(ctx context.Context, self AnyObjectResult, spec *FieldSpec, sel Selection) (AnyResult, error) {
+	if spec.timeout == 0 {
+		return s.selectField(ctx, self, spec, sel.Selector, sel.Directives)
+	}
+
+	ctx, cancel := context.WithTimeoutCause(ctx, spec.timeout, errFieldTimeout)
+	defer cancel()
+
+	type selectResult struct {
+		val AnyResult
+		err error
+	}
+	done := make(chan selectResult, 1)
+	go func() {
+		val, err := s.catchPanic(ctx, self, sel, func() (any, error) {
+			return s.selectField(ctx, self, spec, sel.Selector, sel.Directives)
+		})
+		res, _ := val.(AnyResult)
+		done <- selectResult{res, err}
+	}()
+
+	timeoutErr := Errorf(ErrCodeFieldTimeout, "%s.%s exceeded timeout of %s",
+		self.Type().Name(), sel.Selector.Field, spec.timeout)
+	select {
+	case res := <-done:
+		if res.err != nil && errors.Is(context.Cause(ctx), errFieldTimeout) {
+			return nil, timeoutErr
+		}
+		return res.val, res.err
+	case <-ctx.Done():
+		if errors.Is(context.Cause(ctx), errFieldTimeout) {
+			return nil, timeoutErr
+		}
+		// canceled by the caller, which the resolver is left to handle as usual
+		res := <-done
+		return res.val, res.err
+	}
+}
+
+// timeoutFor returns the timeout of the given operation, or 0 if it has none.
+func (s *Server) timeoutFor(gqlOp *graphql.OperationContext) (time.Duration, error) {
+	timeout := s.queryTimeout
+	if header := gqlOp.Headers.Get(QueryTimeoutHeader); header != "" {
+		d, err := time.ParseDuration(header)
+		if err != nil || d <= 0 {
+			return 0, Errorf(ErrCodeInvalidArgument, "invalid %s header %q: must be a positive duration", QueryTimeoutHeader, header)
+		}
+		if timeout == 0 || d < timeout {
+			timeout = d
+		}
+	}
+	return timeout, nil
+}
+
+// execOpWithTimeout executes the operations of the given document, failing
+// once the timeout is exceeded.
+func (s *Server) execOpWithTimeout(ctx context.Context, gqlOp *graphql.OperationContext, timeout time.Duration) (map[string]any, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, errQueryTimeout)
+	if d := incrementalDeliveryFromContext(ctx); d != nil {
+		// deferred and streamed payloads are resolved after this returns, and are
+		// bound by the same timeout
+		d.afterStop(cancel)
+	} else {
+		defer cancel()
+	}
+
+	type execResult struct {
+		results map[string]any
+		err     error
+	}
+	done := make(chan execResult, 1)
+	// the resolvers may keep running after the timeout, so they're registered
+	// with the shutdown state, for GracefulShutdown to wait for them
+	if !s.shutdown.join() {
+		return nil, errShuttingDown
+	}
+	go func() {
+		defer s.shutdown.end()
+		results, err := s.execOp(ctx, gqlOp)
+		done <- execResult{results, err}
+	}()
+
+	timeoutErr := Errorf(ErrCodeTimeout, "query exceeded timeout of %s", timeout)
+	select {
+	case res := <-done:
+		if res.err != nil && errors.Is(context.Cause(ctx), errQueryTimeout) {
+			return nil, timeoutErr
+		}
+		return res.results, res.err
+	case <-ctx.Done():
+		if errors.Is(context.Cause(ctx), errQueryTimeout) {
+			return nil, timeoutErr
+		}
+		// canceled by the caller, which resolvers are left to handle as usual
+		res := <-done
+		return res.results, res.err
+	}
+}
+
+// checkLimits validates parsed selections against the configured limits.
+func (s *Server) checkLimits(sels []Selection) error {
+	if s.maxDepth > 0 {
+		if depth := selectionDepth(sels); depth > s.maxDepth {
+			return limitErr("query depth %d exceeds the maximum of %d", depth, s.maxDepth)
+		}
+	}
+	if s.maxComplexity > 0 {
+		complexity := s.complexity(s.Root().ObjectType(), s.View, sels)
+		if complexity > s.maxComplexity {
+			return limitErr("query complexity %d exceeds the maximum of %d", complexity, s.maxComplexity)
+		}
+	}
+	return nil
+}
+
+func limitErr(msg string, args ...any) error {
+	err := gqlerror.Errorf(msg, args...)
+	errcode.Set(err, errcode.ValidationFailed)
+	return gqlerror.List{err}
+}
+
+// selectionDepth returns the maximum nesting depth of the given selections.
+func selectionDepth(sels []Selection) int {
+	var depth int
+	for _, sel := range sels {
+		depth = max(depth, 1+selectionDepth(sel.Subselections))
+	}
+	return depth
+}
+
+// complexity computes the complexity of the given selections on an object of
+// the given type.
+func (s *Server) complexity(class ObjectType, view call.View, sels []Selection) int {
+	var total int
+	for _, sel := range sels {
+		fieldComplexity := 1
+		selClass := class
+		if sel.TypeCondition != "" {
+			selClass, _ = s.ObjectType(sel.TypeCondition)
+		}
+		if selClass == nil {
+			total += fieldComplexity
+			continue
+		}
+		spec, ok := selClass.FieldSpec(sel.Selector.Field, view)
+		if !ok {
+			total += fieldComplexity
+			continue
+		}
+		retType := spec.Type.Type()
+		if len(sel.Subselections) > 0 {
+			childClass, _ := s.ObjectType(retType.Name())
+			fieldComplexity += s.complexity(childClass, view, sel.Subselections)
+		}
+		if retType.Elem != nil {
+			if n, ok := listSize(sel.Selector.Args); ok {
+				fieldComplexity *= n
+			}
+		}
+		total += fieldComplexity
+	}
+	return total
+}
+
+// listSize returns the requested size of a list field, if bounded by one of
+// the listSizeArgs.
+func listSize(args []NamedInput) (int, bool) {
+	for _, name := range listSizeArgs {
+		val, ok := Inputs(args).Lookup(name)
+		if !ok {
+			continue
+		}
+		if n, ok := intValue(val); ok && n > 0 {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func intValue(val Typed) (int, bool) {
+	switch x := val.(type) {
+	case Int:
+		return x.Int(), true
+	case Derefable:
+		inner, ok := x.Deref()
+		if !ok {
+			return 0, false
+		}
+		return intValue(inner)
+	default:
+		return 0, false
+	}
+}
+
+// concurrencyLimit bounds the number of goroutines resolving selections for a
+// single request. A nil limit is unbounded.
+type concurrencyLimit chan struct{}
+
+func (limit concurrencyLimit) tryAcquire() bool {
+	if limit == nil {
+		return true
+	}
+	select {
+	case limit <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (limit concurrencyLimit) release() {
+	if limit != nil {
+		<-limit
+	}
+}
+
+type concurrencyLimitCtx struct{}
+
+func concurrencyLimitToContext(ctx context.Context, limit concurrencyLimit) context.Context {
+	return context.WithValue(ctx, concurrencyLimitCtx{}, limit)
+}
+
+func concurrencyLimitFromContext(ctx context.Context) concurrencyLimit {
+	limit, _ := ctx.Value(concurrencyLimitCtx{}).(concurrencyLimit)
+	return limit
+}
@@ -0,0 +1,111 @@
+package dagql
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+)
+
+// mergeableClass is implemented by object types that can be merged into
+// another server.
+type mergeableClass interface {
+	ObjectType
+	cloneFor(*Server) ObjectType
+	mergeFields(other ObjectType, check bool) error
+}
+
+// Merge installs the types of the other server into this one, e.g. to serve
+// the schemas of several subsystems from a single gateway server.
+//
+// Object types defined by both servers must have the same Go type, and have
+// their field lists merged. Fields defined by both must have the same return
+// type, or an error is returned and neither server is modified. Otherwise, the
+// fields, scalars, and other types already installed on this server take
+// precedence.
+func (s *Server) Merge(other *Server) error {
+	other.installLock.Lock()
+	objects := maps.Clone(other.objects)
+	scalars := maps.Clone(other.scalars)
+	typeDefs := maps.Clone(other.typeDefs)
+	directives := maps.Clone(other.directives)
+	interfaces := maps.Clone(other.interfaces)
+	implements := maps.Clone(other.implements)
+	unions := maps.Clone(other.unions)
+	other.installLock.Unlock()
+
+	names := slices.Collect(maps.Keys(objects))
+	sort.Strings(names)
+
+	var added []ObjectType
+	merged := map[string]mergeableClass{}
+	for _, name := range names {
+		class := objects[name]
+		existing, ok := s.ObjectType(name)
+		if !ok {
+			if mergeable, ok := class.(mergeableClass); ok {
+				class = mergeable.cloneFor(s)
+			}
+			added = append(added, class)
+			continue
+		}
+		mergeable, ok := existing.(mergeableClass)
+		if !ok {
+			return fmt.Errorf("type %s cannot be merged", name)
+		}
+		if err := mergeable.mergeFields(class, true); err != nil {
+			return err
+		}
+		merged[name] = mergeable
+	}
+
+	// install new types first so that their load<Type>FromID fields are bound
+	// to this server rather than the other one
+	for _, class := range added {
+		s.InstallObject(class)
+	}
+	for _, name := range names {
+		if mergeable, ok := merged[name]; ok {
+			if err := mergeable.mergeFields(objects[name], false); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.installLock.Lock()
+	defer s.installLock.Unlock()
+	for name, scalar := range scalars {
+		if _, ok := s.scalars[name]; !ok {
+			s.scalars[name] = scalar
+		}
+	}
+	for name, def := range typeDefs {
+		if _, ok := s.typeDefs[name]; !ok {
+			s.typeDefs[name] = def
+		}
+	}
+	for name, directive := range directives {
+		if _, ok := s.directives[name]; !ok {
+			s.directives[name] = directive
+		}
+	}
+	for name, iface := range interfaces {
+		if _, ok := s.interfaces[name]; !ok {
+			s.interfaces[name] = iface
+		}
+	}
+	for impl, ifaces := range implements {
+		for _, iface := range ifaces {
+			if !slices.Contains(s.implements[impl], iface) {
+				s.implements[impl] = append(s.implements[impl], iface)
+			}
+		}
+	}
+	for name, u := range unions {
+		if _, ok := s.unions[name]; !ok {
+			s.unions[name] = u
+		}
+	}
+	s.invalidateSchemaCache()
+	return nil
+}
@@ -0,0 +1,144 @@
+package dagql
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/protobuf/proto"
+)
+
+// serverMetrics are the prometheus metrics recorded by a server.
+type serverMetrics struct {
+	registry *prometheus.Registry
+
+	// fieldDuration records the duration of field selections, by type and
+	// field name.
+	fieldDuration *prometheus.HistogramVec
+	// cacheResults counts the selections of cacheable fields, by whether they
+	// hit the cache.
+	cacheResults *prometheus.CounterVec
+}
+
+func newServerMetrics() *serverMetrics {
+	m := &serverMetrics{
+		registry: prometheus.NewRegistry(),
+		fieldDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "dagql_field_duration_seconds",
+			Help:    "Duration of field selections, including cached ones",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"type", "field"}),
+		cacheResults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dagql_cache_results_total",
+			Help: "Number of selections of cacheable fields, by whether they hit the cache",
+		}, []string{"result"}),
+	}
+	m.registry.MustRegister(m.fieldDuration, m.cacheResults)
+	return m
+}
+
+// WithMetrics returns a copy of the server that records prometheus metrics of
+// the duration of every field selected by a query, and of the cache hits and
+// misses of the fields.
+//
+// The metrics are also registered with the given registerer, if not nil, and
+// are always served by MetricsHandler.
+func (s *Server) WithMetrics(registerer prometheus.Registerer) (*Server, error) {
+	metrics := newServerMetrics()
+	if registerer != nil {
+		if err := registerer.Register(metrics.fieldDuration); err != nil {
+			return nil, err
+		}
+		if err := registerer.Register(metrics.cacheResults); err != nil {
+			registerer.Unregister(metrics.fieldDuration)
+			return nil, err
+		}
+	}
+	cp := *s
+	cp.metrics = metrics
+	return &cp, nil
+}
+
+// MetricsHandler returns a handler serving the metrics of the server in the
+// prometheus exposition format, e.g. at /metrics.
+//
+// If the server doesn't record metrics, the handler responds with 404.
+func (s *Server) MetricsHandler() http.Handler {
+	if s.metrics == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(s.metrics.registry, promhttp.HandlerOpts{})
+}
+
+// CacheStats is a snapshot of the state of a server's cache.
+//
+// The counters and sizes are those of the underlying cache, so they include
+// the results of all the sessions sharing it, not just the server's own.
+type CacheStats struct {
+	// Hits is the number of calls that were served by a cached result.
+	Hits uint64
+	// Misses is the number of calls that had to run.
+	Misses uint64
+	// Evictions is the number of results removed from the cache due to its
+	// TTL or max size.
+	Evictions uint64
+	// Size is the number of entries in the cache.
+	Size int
+	// IDBytes is the encoded size of the calls of the cached results' IDs.
+	// It doesn't account for the values they hold, so it's only a lower
+	// bound of the memory used by the cache.
+	IDBytes int64
+}
+
+// CacheStats returns the current counters of the server's cache.
+func (s *Server) CacheStats() CacheStats {
+	if s.Cache == nil {
+		return CacheStats{}
+	}
+	counters := s.Cache.Stats()
+	stats := CacheStats{
+		Hits:      counters.Hits,
+		Misses:    counters.Misses,
+		Evictions: counters.Evictions,
+		Size:      s.Cache.Size(),
+	}
+	s.Cache.Range(func(_ CacheKeyType, res CacheValueType) bool {
+		if res != nil && res.ID() != nil {
+			stats.IDBytes += int64(proto.Size(res.ID().Call()))
+		}
+		return true
+	})
+	return stats
+}
+
+// CacheStatsHandler returns a handler serving the server's CacheStats as JSON,
+// e.g. at /debug/dagql/cache.
+func (s *Server) CacheStatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.CacheStats())
+	})
+}
+
+// observeField records the duration of a field selection started at the given
+// time.
+func (m *serverMetrics) observeField(typeName, fieldName string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.fieldDuration.WithLabelValues(typeName, fieldName).Observe(time.Since(start).Seconds())
+}
+
+// observeCache records whether a selection of a cacheable field hit the cache.
+func (m *serverMetrics) observeCache(hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheResults.WithLabelValues(result).Inc()
+}
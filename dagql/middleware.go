@@ -0,0 +1,105 @@
+package dagql
+
+import (
+	"context"
+	"slices"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// SelectFunc selects a field on an object.
+type SelectFunc func(ctx context.Context, self AnyObjectResult, sel Selector) (AnyResult, error)
+
+// FieldMiddleware is called around the selection of every field in a query,
+// e.g. for auth checks, rate limiting, or audit logging.
+//
+// The middleware must call next to continue the selection, and may modify the
+// context, object, or selector that are passed along.
+type FieldMiddleware func(ctx context.Context, self AnyObjectResult, sel Selector, next SelectFunc) (AnyResult, error)
+
+// Use installs a middleware to be called around every field selected by a
+// query. Middlewares are chained in the order they are installed, so the
+// first middleware installed is the outermost.
+func (s *Server) Use(mw FieldMiddleware) {
+	s.updateHandlers(func(h *handlerSet) {
+		h.middlewares = append(h.middlewares, mw)
+	})
+}
+
+// classMiddlewares is implemented by object types that can have middlewares
+// of their own.
+type classMiddlewares interface {
+	fieldMiddlewares() []FieldMiddleware
+}
+
+// selectField selects the given field on the object, passing through all
+// middlewares installed on the server and then on the object's class, and the
+// handlers of the field's directives and of those applied to it by the query.
+func (s *Server) selectField(ctx context.Context, self AnyObjectResult, spec *FieldSpec, sel Selector, directives []AppliedDirective) (AnyResult, error) {
+	handlers := s.handlers.Load()
+	mws := handlers.middlewares
+	hasDirectiveHandlers := len(handlers.directiveHandlers) > 0
+	if class, ok := self.ObjectType().(classMiddlewares); ok {
+		mws = append(slices.Clip(mws), class.fieldMiddlewares()...)
+	}
+
+	next := SelectFunc(func(ctx context.Context, self AnyObjectResult, sel Selector) (AnyResult, error) {
+		if err := s.runObjectHooks(ctx, self, func(hook ObjectHook) ObjectHookFunc {
+			return hook.OnSelect
+		}); err != nil {
+			return nil, err
+		}
+		return self.Select(ctx, s, sel)
+	})
+	if hasDirectiveHandlers {
+		defDirectives, err := s.handledDirectives(spec.Directives, nil)
+		if err != nil {
+			return nil, err
+		}
+		directives = append(slices.Clip(defDirectives), directives...)
+		next = s.withDirectiveHandlers(next, directives)
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		mw, inner := mws[i], next
+		next = func(ctx context.Context, self AnyObjectResult, sel Selector) (AnyResult, error) {
+			return mw(ctx, self, sel, inner)
+		}
+	}
+	return next(ctx, self, sel)
+}
+
+// ExecMiddleware is called around the execution of every request served
+// through Exec, ServeHTTP or SSEHandler, e.g. for auth token validation,
+// request logging, or panic recovery.
+//
+// Unlike a FieldMiddleware, it is called once per request, and must call next
+// to execute it.
+type ExecMiddleware func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response
+
+// UseExec installs a middleware to be called around every request served
+// through Exec, ServeHTTP or SSEHandler. Middlewares are chained in the order
+// they are installed, so the first middleware installed is the outermost.
+func (s *Server) UseExec(mw ExecMiddleware) {
+	s.updateHandlers(func(h *handlerSet) {
+		h.execMiddlewares = append(h.execMiddlewares, mw)
+	})
+}
+
+// execHandler wraps the handler executing a request with everything that
+// surrounds the execution of every request, whichever way it's served: the
+// exec middlewares and the request's trace ID.
+func (s *Server) execHandler(handler graphql.ResponseHandler) graphql.ResponseHandler {
+	return withTraceID(s.wrapExec(handler))
+}
+
+// wrapExec wraps the response handler with all installed exec middlewares.
+func (s *Server) wrapExec(handler graphql.ResponseHandler) graphql.ResponseHandler {
+	mws := s.handlers.Load().execMiddlewares
+	for i := len(mws) - 1; i >= 0; i-- {
+		mw, inner := mws[i], handler
+		handler = func(ctx context.Context) *graphql.Response {
+			return mw(ctx, inner)
+		}
+	}
+	return handler
+}
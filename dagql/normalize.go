@@ -0,0 +1,100 @@
+package dagql
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// NormalizeQuery returns the canonical form of a query document, so that
+// queries selecting the same data render the same way. Aliases are stripped,
+// the arguments of fields and directives and the fields of input objects are
+// sorted by name, and the document is formatted canonically.
+//
+// The normalized query identifies the selected data rather than the shape of
+// the response, which depends on the aliases, so it's meant for cache keys and
+// not for execution.
+func NormalizeQuery(query string) (string, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return "", err
+	}
+	for _, op := range doc.Operations {
+		normalizeDirectives(op.Directives)
+		for _, varDef := range op.VariableDefinitions {
+			normalizeValue(varDef.DefaultValue)
+			normalizeDirectives(varDef.Directives)
+		}
+		normalizeSelections(op.SelectionSet)
+	}
+	for _, frag := range doc.Fragments {
+		normalizeDirectives(frag.Directives)
+		normalizeSelections(frag.SelectionSet)
+	}
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatQueryDocument(doc)
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// NormalizedQueryHash returns the hex-encoded SHA-256 hash of the normalized
+// form of a query document.
+func NormalizedQueryHash(query string) (string, error) {
+	normalized, err := NormalizeQuery(query)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func normalizeSelections(sels ast.SelectionSet) {
+	for _, sel := range sels {
+		switch x := sel.(type) {
+		case *ast.Field:
+			x.Alias = x.Name
+			normalizeArguments(x.Arguments)
+			normalizeDirectives(x.Directives)
+			normalizeSelections(x.SelectionSet)
+		case *ast.InlineFragment:
+			normalizeDirectives(x.Directives)
+			normalizeSelections(x.SelectionSet)
+		case *ast.FragmentSpread:
+			normalizeDirectives(x.Directives)
+		}
+	}
+}
+
+func normalizeDirectives(directives ast.DirectiveList) {
+	for _, directive := range directives {
+		normalizeArguments(directive.Arguments)
+	}
+}
+
+func normalizeArguments(args ast.ArgumentList) {
+	slices.SortStableFunc(args, func(a, b *ast.Argument) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, arg := range args {
+		normalizeValue(arg.Value)
+	}
+}
+
+func normalizeValue(val *ast.Value) {
+	if val == nil {
+		return
+	}
+	if val.Kind == ast.ObjectValue {
+		slices.SortStableFunc(val.Children, func(a, b *ast.ChildValue) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+	for _, child := range val.Children {
+		normalizeValue(child.Value)
+	}
+}
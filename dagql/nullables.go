@@ -129,6 +129,14 @@ func (o Optional[I]) Deref() (Typed, bool) {
 	return o.Value, o.Valid
 }
 
+func (o Optional[I]) withValue(val Input) (Input, error) {
+	v, ok := val.(I)
+	if !ok {
+		return nil, fmt.Errorf("expected %T, got %T", v, val)
+	}
+	return Opt(v), nil
+}
+
 func (o *Optional[I]) UnmarshalJSON(p []byte) error {
 	if err := json.Unmarshal(p, &o.Value); err != nil {
 		return err
@@ -233,6 +241,10 @@ func (o DynamicOptional) Deref() (Typed, bool) {
 	return o.Value, o.Valid
 }
 
+func (o DynamicOptional) withValue(val Input) (Input, error) {
+	return DynamicOptional{Elem: o.Elem, Value: val, Valid: true}, nil
+}
+
 func (o DynamicOptional) MarshalJSON() ([]byte, error) {
 	if !o.Valid {
 		return json.Marshal(nil)
@@ -10,10 +10,12 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/iancoleman/strcase"
 	"github.com/opencontainers/go-digest"
 	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/time/rate"
 
 	"github.com/dagger/dagger/dagql/call"
 	"github.com/dagger/dagger/engine"
@@ -31,6 +33,14 @@ type Class[T Typed] struct {
 	fields  map[string][]*Field[T]
 	fieldsL *sync.Mutex
 
+	// description overrides the description of the type, if set. It is guarded
+	// by fieldsL.
+	description *string
+
+	// middlewares are called around the selection of the fields of the class,
+	// within the server's middlewares. They are guarded by fieldsL.
+	middlewares *[]FieldMiddleware
+
 	invalidateSchemaCache func()
 }
 
@@ -58,6 +68,9 @@ func NewClass[T Typed](srv *Server, opts_ ...ClassOpts[T]) Class[T] {
 		fields:  map[string][]*Field[T]{},
 		fieldsL: new(sync.Mutex),
 
+		description: new(string),
+		middlewares: new([]FieldMiddleware),
+
 		invalidateSchemaCache: srv.invalidateSchemaCache,
 	}
 	if !opts.NoIDs {
@@ -105,6 +118,30 @@ func (class Class[T]) FieldSpec(name string, view call.View) (FieldSpec, bool) {
 	return *field.Spec, true
 }
 
+func (class Class[T]) FieldSpecs(view call.View) []FieldSpec {
+	class.fieldsL.Lock()
+	defer class.fieldsL.Unlock()
+	specs := make([]FieldSpec, 0, len(class.fields))
+	for name := range class.fields {
+		if field, ok := class.fieldLocked(name, view); ok {
+			specs = append(specs, *field.Spec)
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// cacheSpec returns the cache spec of the field, for planning queries.
+func (class Class[T]) cacheSpec(name string, view call.View) (CacheSpec, bool) {
+	field, ok := class.Field(name, view)
+	if !ok {
+		return CacheSpec{}, false
+	}
+	return field.CacheSpec, true
+}
+
 func (class Class[T]) fieldLocked(name string, view call.View) (Field[T], bool) {
 	fields, ok := class.fields[name]
 	if !ok {
@@ -124,6 +161,37 @@ func (class Class[T]) fieldLocked(name string, view call.View) (Field[T], bool)
 	return Field[T]{}, false
 }
 
+// Middleware installs a middleware to be called around the selection of every
+// field of the class, e.g. for auth checks that apply to a whole type.
+//
+// Class middlewares are called after the middlewares installed on the server
+// with Server.Use, and are chained in the order they are installed.
+func (class Class[T]) Middleware(mw FieldMiddleware) {
+	class.fieldsL.Lock()
+	defer class.fieldsL.Unlock()
+	*class.middlewares = append(*class.middlewares, mw)
+}
+
+// fieldMiddlewares returns the middlewares installed on the class.
+func (class Class[T]) fieldMiddlewares() []FieldMiddleware {
+	if class.middlewares == nil {
+		return nil
+	}
+	class.fieldsL.Lock()
+	defer class.fieldsL.Unlock()
+	return *class.middlewares
+}
+
+// setDescription sets the description of the type, overriding its own.
+func (class Class[T]) setDescription(desc string) {
+	class.fieldsL.Lock()
+	*class.description = desc
+	class.fieldsL.Unlock()
+	if class.invalidateSchemaCache != nil {
+		class.invalidateSchemaCache()
+	}
+}
+
 func (class Class[T]) Install(fields ...Field[T]) {
 	class.fieldsL.Lock()
 	defer class.fieldsL.Unlock()
@@ -160,6 +228,69 @@ func (class Class[T]) Install(fields ...Field[T]) {
 
 var _ ObjectType = Class[Typed]{}
 
+// cloneFor returns a copy of the class for installing into another server.
+func (class Class[T]) cloneFor(srv *Server) ObjectType {
+	class.fieldsL.Lock()
+	defer class.fieldsL.Unlock()
+	clone := class
+	clone.fields = make(map[string][]*Field[T], len(class.fields))
+	for name, fields := range class.fields {
+		clone.fields[name] = slices.Clone(fields)
+	}
+	clone.fieldsL = new(sync.Mutex)
+	clone.description = new(string)
+	*clone.description = *class.description
+	clone.middlewares = new([]FieldMiddleware)
+	*clone.middlewares = slices.Clone(*class.middlewares)
+	clone.invalidateSchemaCache = srv.invalidateSchemaCache
+	return clone
+}
+
+// mergeFields adds the fields of other, which must be a class of the same
+// type, that aren't already defined. Fields defined by both must have the
+// same return type.
+//
+// If check is true, conflicts are only checked for, and no fields are added.
+func (class Class[T]) mergeFields(other ObjectType, check bool) error {
+	otherClass, ok := other.(Class[T])
+	if !ok {
+		return fmt.Errorf("type %s is implemented by %T and %T", class.TypeName(), class, other)
+	}
+	if otherClass.fieldsL == class.fieldsL {
+		// already the same class
+		return nil
+	}
+	otherClass.fieldsL.Lock()
+	otherFields := make(map[string][]*Field[T], len(otherClass.fields))
+	for name, fields := range otherClass.fields {
+		otherFields[name] = slices.Clone(fields)
+	}
+	otherClass.fieldsL.Unlock()
+
+	class.fieldsL.Lock()
+	for name, fields := range otherFields {
+		existing := class.fields[name]
+		if len(existing) == 0 {
+			if !check {
+				class.fields[name] = fields
+			}
+			continue
+		}
+		oldType := existing[len(existing)-1].Spec.Type.Type()
+		newType := fields[len(fields)-1].Spec.Type.Type()
+		if oldType.String() != newType.String() {
+			class.fieldsL.Unlock()
+			return fmt.Errorf("field %s.%s has conflicting types %s and %s", class.TypeName(), name, oldType, newType)
+		}
+	}
+	class.fieldsL.Unlock()
+
+	if !check && class.invalidateSchemaCache != nil {
+		class.invalidateSchemaCache()
+	}
+	return nil
+}
+
 func (class Class[T]) TypeName() string {
 	return class.inner.Type().Name()
 }
@@ -206,6 +337,9 @@ func (class Class[T]) TypeDefinition(view call.View) *ast.Definition {
 	if isType, ok := val.(Descriptive); ok {
 		def.Description = isType.TypeDescription()
 	}
+	if class.description != nil && *class.description != "" {
+		def.Description = *class.description
+	}
 	for name := range class.fields {
 		if field, ok := class.fieldLocked(name, view); ok {
 			def.Fields = append(def.Fields, field.FieldDefinition(view))
@@ -218,6 +352,19 @@ func (class Class[T]) TypeDefinition(view call.View) *ast.Definition {
 	return def
 }
 
+// coerceList wraps a single value passed for a list type into a list of one,
+// as required by GraphQL input coercion, e.g. so that `args: "build"` is the
+// same as `args: ["build"]`.
+func coerceList(typ *ast.Type, val any) any {
+	if typ.Elem == nil || val == nil {
+		return val
+	}
+	if _, isList := val.([]any); isList {
+		return val
+	}
+	return []any{coerceList(typ.Elem, val)}
+}
+
 // ParseField parses a field selection into a Selector and return type.
 func (class Class[T]) ParseField(ctx context.Context, view call.View, astField *ast.Field, vars map[string]any) (Selector, *ast.Type, error) {
 	field, ok := class.Field(astField.Name, view)
@@ -239,7 +386,7 @@ func (class Class[T]) ParseField(ctx context.Context, view call.View, astField *
 		if err != nil {
 			return Selector{}, nil, err
 		}
-		input, err := argSpec.Type.Decoder().DecodeInput(val)
+		input, err := decodeArg(ctx, argSpec, coerceList(argSpec.Type.Type(), val))
 		if err != nil {
 			return Selector{}, nil, fmt.Errorf("init arg %q value as %T (%s) using %T: %w", arg.Name, argSpec.Type, argSpec.Type.Type(), argSpec.Type.Decoder(), err)
 		}
@@ -248,6 +395,13 @@ func (class Class[T]) ParseField(ctx context.Context, view call.View, astField *
 			Value: input,
 		}
 	}
+	if err := checkArgCount(class.TypeName(), field.Spec, len(args)); err != nil {
+		return Selector{}, nil, err
+	}
+	// keep the selector independent of the order the arguments were written in
+	slices.SortFunc(args, func(a, b NamedInput) int {
+		return strings.Compare(a.Name, b.Name)
+	})
 	if field.Spec.ViewFilter == nil {
 		// fields in the global view shouldn't attach the current view to the
 		// selector (since they're global from all perspectives)
@@ -330,6 +484,10 @@ type Result[T Typed] struct {
 	constructor *call.ID
 	self        T
 	postCall    cache.PostCallFunc
+
+	// annotations is runtime metadata attached to the result, which isn't part
+	// of its ID.
+	annotations map[string]string
 }
 
 var _ AnyResult = Result[Typed]{}
@@ -392,7 +550,27 @@ func (r Result[T]) WithDigest(customDigest digest.Digest) Result[T] {
 	return Result[T]{
 		constructor: r.constructor.WithDigest(customDigest),
 		self:        r.self,
+		annotations: r.annotations,
+	}
+}
+
+// WithAnnotation returns an updated instance with the given annotation set,
+// e.g. to tell middleware and hooks where the value came from. Annotations
+// don't affect the ID of the instance, and so aren't considered for caching.
+func (r Result[T]) WithAnnotation(key, value string) Result[T] {
+	r.annotations = maps.Clone(r.annotations)
+	if r.annotations == nil {
+		r.annotations = map[string]string{}
 	}
+	r.annotations[key] = value
+	return r
+}
+
+// Annotation returns the value of the annotation set on the instance for the
+// given key, if any.
+func (r Result[T]) Annotation(key string) (string, bool) {
+	value, ok := r.annotations[key]
+	return value, ok
 }
 
 // String returns the instance in Class@sha256:... format.
@@ -441,11 +619,19 @@ func (r ObjectResult[T]) WithObjectDigest(customDigest digest.Digest) ObjectResu
 		Result: Result[T]{
 			constructor: r.constructor.WithDigest(customDigest),
 			self:        r.self,
+			annotations: r.annotations,
 		},
 		class: r.class,
 	}
 }
 
+// WithAnnotation returns an updated instance with the given annotation set.
+// See Result.WithAnnotation.
+func (r ObjectResult[T]) WithAnnotation(key, value string) ObjectResult[T] {
+	r.Result = r.Result.WithAnnotation(key, value)
+	return r
+}
+
 func NoopDone(res AnyResult, cached bool, rerr error) {}
 
 // Select calls the field on the instance specified by the selector
@@ -483,6 +669,9 @@ func (r ObjectResult[T]) preselect(ctx context.Context, s *Server, sel Selector)
 	if !ok {
 		return nil, fmt.Errorf("Select: %s has no such field: %q", r.class.TypeName(), sel.Field)
 	}
+	if err := checkCall(ctx, r, field.Spec, sel.Args); err != nil {
+		return nil, err
+	}
 	if field.Spec.ViewFilter == nil {
 		// fields in the global view shouldn't attach the current view to the
 		// selector (since they're global from all perspectives)
@@ -494,22 +683,20 @@ func (r ObjectResult[T]) preselect(ctx context.Context, s *Server, sel Selector)
 	for _, argSpec := range field.Spec.Args.Inputs(view) {
 		// just be n^2 since the overhead of a map is likely more expensive
 		// for the expected low value of n
-		var namedInput NamedInput
-		for _, selArg := range sel.Args {
-			if selArg.Name == argSpec.Name {
-				namedInput = selArg
-				break
-			}
+		input, _ := sel.Arg(argSpec.Name)
+		input, err := decodeLiteralInput(argSpec, input)
+		if err != nil {
+			return nil, fmt.Errorf("decode arg %q: %w", argSpec.Name, err)
 		}
 
 		switch {
-		case namedInput.Value != nil:
+		case input != nil:
 			idArgs = append(idArgs, call.NewArgument(
-				namedInput.Name,
-				namedInput.Value.ToLiteral(),
+				argSpec.Name,
+				input.ToLiteral(),
 				argSpec.Sensitive,
 			))
-			inputArgs[argSpec.Name] = namedInput.Value
+			inputArgs[argSpec.Name] = input
 
 		case argSpec.Default != nil:
 			inputArgs[argSpec.Name] = argSpec.Default
@@ -606,6 +793,16 @@ func (r ObjectResult[T]) Call(ctx context.Context, s *Server, newID *call.ID) (A
 	if err != nil {
 		return nil, err
 	}
+	// check the arguments set in the ID, as they were when it was selected
+	var args []NamedInput
+	for _, arg := range newID.Args() {
+		if input, ok := inputArgs[arg.Name()]; ok {
+			args = append(args, NamedInput{Name: arg.Name(), Value: input})
+		}
+	}
+	if err := checkCall(ctx, r, field.Spec, args); err != nil {
+		return nil, err
+	}
 
 	doNotCache := field.CacheSpec.DoNotCache != ""
 	return r.call(ctx, s, newID, inputArgs, doNotCache)
@@ -681,7 +878,16 @@ func (r ObjectResult[T]) call(
 		cacheKey.ConcurrencyKey = clientMD.ClientID
 	}
 
-	res, err := s.Cache.GetOrInitializeWithCallbacks(ctx, cacheKey, func(ctx context.Context) (*CacheValWithCallbacks, error) {
+	res, err := s.Cache.GetOrInitializeWithCallbacks(ctx, cacheKey, func(ctx context.Context) (_ *CacheValWithCallbacks, rerr error) {
+		// the cache calls this in its own goroutine, which a panic would crash
+		defer func() {
+			if p := recover(); p != nil {
+				rerr = s.panicError(ctx, p, r, Selection{
+					Selector: Selector{Field: newID.Field(), View: newID.View()},
+				})
+			}
+		}()
+
 		valWithCallbacks, err := r.class.Call(ctx, s, r, newID.Field(), newID.View(), inputArgs)
 		if err != nil {
 			return nil, err
@@ -718,6 +924,17 @@ func (r ObjectResult[T]) call(
 	if err != nil {
 		return nil, err
 	}
+	if !doNotCache {
+		s.metrics.observeCache(res.HitCache())
+		event := OnCacheMiss
+		if res.HitCache() {
+			event = OnCacheHit
+		}
+		s.emit(ctx, event, HookData{
+			Self: r,
+			ID:   newID,
+		})
+	}
 	if err := res.PostCall(ctx); err != nil {
 		return nil, fmt.Errorf("post-call error: %w", err)
 	}
@@ -734,7 +951,7 @@ func (r ObjectResult[T]) call(
 		}
 
 		// only need to add a new cache key if the returned val has a different custom digest than the original
-		digestChanged := valID.Digest() != newID.Digest()
+		digestChanged := !valID.Equals(newID)
 
 		// Corner case: the `id` field on an object returns an IDable value (IDs are themselves both values and IDable).
 		// However, if we cached `val` in this case, we would be caching <id digest> -> <id value>, which isn't what we
@@ -938,6 +1155,23 @@ type FieldSpec struct {
 	// extend is used during installation to copy the spec of a previous field
 	// with the same name
 	extend bool
+
+	// installTypes installs the types that the field depends on, if set, such
+	// as the connection types of a paginated field.
+	installTypes func(*Server)
+
+	// limiter limits the rate at which the field is selected, if set.
+	limiter *rate.Limiter
+
+	// timeout bounds the duration of the field's selection, if set.
+	timeout time.Duration
+
+	// authorize decides whether the field may be selected, if set.
+	authorize AuthFunc
+
+	// minArgs and maxArgs bound the number of arguments passed to the field,
+	// if non-zero.
+	minArgs, maxArgs int
 }
 
 func (spec FieldSpec) FieldDefinition(view call.View) *ast.FieldDefinition {
@@ -1048,6 +1282,8 @@ func (arg Argument) Internal() Argument {
 	return arg
 }
 
+// Default sets the value of the argument when it's omitted. When passed to
+// Field.Args, the value is coerced to the type of the argument.
 func (arg Argument) Default(input Input) Argument {
 	arg.Spec.Default = input
 	return arg
@@ -1065,6 +1301,9 @@ func (arg Argument) Deprecated(paras ...string) Argument {
 		return arg
 	}
 	arg.Spec.DeprecatedReason = FormatDescription(paras...)
+	if arg.Spec.DeprecatedReason == "" {
+		arg.Spec.DeprecatedReason = defaultDeprecationReason
+	}
 	return arg
 }
 
@@ -1253,9 +1492,87 @@ func (fields Fields[T]) Install(server *Server) {
 			},
 		})
 	}
+	for _, field := range fields {
+		if field.Spec.installTypes != nil {
+			field.Spec.installTypes(server)
+		}
+		server.checkFieldTimeout(class.TypeName(), field.Spec)
+	}
 	class.Install(fields...)
 }
 
+// Doc returns the fields along with a description of their Object type, which
+// is set when they're installed. Each argument is joined by two empty lines.
+//
+// The description takes precedence over one provided by the type itself via
+// Descriptive.
+func (fields Fields[T]) Doc(paras ...string) DocumentedFields[T] {
+	return DocumentedFields[T]{
+		Fields:      fields,
+		Description: FormatDescription(paras...),
+	}
+}
+
+// Rename renames the field oldName to newName, and keeps oldName as a deprecated
+// alias resolved by the same function, so that clients can migrate to the new
+// name gradually.
+func (fields Fields[T]) Rename(oldName, newName string) Fields[T] {
+	renamed := slices.Clone(fields)
+	var aliases Fields[T]
+	for i, field := range fields {
+		if field.Spec.extend || field.Spec.Name != oldName {
+			continue
+		}
+		newSpec := *field.Spec
+		newSpec.Name = newName
+		renamed[i].Spec = &newSpec
+
+		oldSpec := *field.Spec
+		field.Spec = &oldSpec
+		aliases = append(aliases, field.Deprecated(fmt.Sprintf("Use `%s` instead.", newName)))
+	}
+	if len(aliases) == 0 {
+		panic(fmt.Sprintf("cannot rename field %q: no such field", oldName))
+	}
+	return append(renamed, aliases...)
+}
+
+// Extend returns the fields followed by the other fields, so that fields
+// defined across files can be installed together.
+//
+// It panics if both define a field of the same name, unless both are limited
+// to views, as when installing them.
+func (fields Fields[T]) Extend(other Fields[T]) Fields[T] {
+	for _, field := range other {
+		if field.Spec.extend {
+			continue
+		}
+		for _, existing := range fields {
+			if existing.Spec.extend || existing.Spec.Name != field.Spec.Name {
+				continue
+			}
+			if existing.Spec.ViewFilter == nil || field.Spec.ViewFilter == nil {
+				panic(fmt.Sprintf("cannot extend fields: field %q is already defined", field.Spec.Name))
+			}
+		}
+	}
+	return append(slices.Clip(fields), other...)
+}
+
+// DocumentedFields is a group of fields that also describes their Object type.
+type DocumentedFields[T Typed] struct {
+	Fields      Fields[T]
+	Description string
+}
+
+// Install installs the fields like Fields.Install, and sets the description of
+// their Object type.
+func (fields DocumentedFields[T]) Install(server *Server) {
+	fields.Fields.Install(server)
+	class := server.InstallObject(NewClass[T](server)).(Class[T])
+	class.setDescription(fields.Description)
+}
+
 type CacheSpec struct {
 	// If set, this GetCacheConfig will be called before ID evaluation to determine the
 	// ID's digest. Otherwise the ID defaults to the digest of the call chain.
@@ -1300,6 +1617,32 @@ func (field Field[T]) View(view ViewFilter) Field[T] {
 	return field
 }
 
+// Cached sets the key the field's results are cached by to the one returned by
+// keyFn for the field's arguments, in place of the digest of its ID. The key is
+// mixed with the digest of the object the field is selected on, so results are
+// only shared between selections on the same object.
+//
+// Any cache config the field already has, e.g. from FuncWithCacheKey, is then
+// applied to the new key.
+func (field Field[T]) Cached(keyFn func(args map[string]Input) digest.Digest) Field[T] {
+	if field.Spec.extend {
+		panic("cannot call on extended field")
+	}
+	getCacheConfig := field.CacheSpec.GetCacheConfig
+	field.CacheSpec.GetCacheConfig = func(ctx context.Context, self AnyResult, argVals map[string]Input, view call.View, baseCfg CacheConfig) (*CacheConfig, error) {
+		var receiver string
+		if id := self.ID(); id != nil {
+			receiver = id.Digest().String()
+		}
+		baseCfg.Digest = HashFrom(receiver, keyFn(argVals).String())
+		if getCacheConfig == nil {
+			return &baseCfg, nil
+		}
+		return getCacheConfig(ctx, self, argVals, view, baseCfg)
+	}
+	return field
+}
+
 // DoNotCache marks the field as not to be stored in the cache for the given reason why
 func (field Field[T]) DoNotCache(reason string, paras ...string) Field[T] {
 	if field.Spec.extend {
@@ -1309,6 +1652,71 @@ func (field Field[T]) DoNotCache(reason string, paras ...string) Field[T] {
 	return field
 }
 
+// RateLimit limits the rate at which the field may be selected, across all
+// queries, to rps selections per second with bursts of up to burst
+// selections. Selections over the limit wait for their turn, and fail with
+// ErrCodeResourceExhausted if their context is done first.
+//
+// It panics unless rps is positive and burst is at least 1, since no
+// selection could ever pass such a limit.
+func (field Field[T]) RateLimit(rps float64, burst int) Field[T] {
+	if field.Spec.extend {
+		panic("cannot call on extended field")
+	}
+	if rps <= 0 || burst < 1 {
+		panic(fmt.Sprintf("invalid rate limit for %s: %v/s with bursts of %d", field.Spec.Name, rps, burst))
+	}
+	field.Spec.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	return field
+}
+
+// MaxArgs limits the number of arguments that a selection of the field may
+// pass to n. Queries passing more are rejected before any field is resolved.
+func (field Field[T]) MaxArgs(n int) Field[T] {
+	if field.Spec.extend {
+		panic("cannot call on extended field")
+	}
+	field.Spec.maxArgs = n
+	return field
+}
+
+// MinArgs requires selections of the field to pass at least n arguments, e.g.
+// when any one of several optional arguments must be set. Queries passing
+// fewer are rejected before any field is resolved.
+func (field Field[T]) MinArgs(n int) Field[T] {
+	if field.Spec.extend {
+		panic("cannot call on extended field")
+	}
+	field.Spec.minArgs = n
+	return field
+}
+
+// Authorize gates each selection of the field on fn, which is called with the
+// request's context and the selection's arguments before the field is
+// resolved. If fn returns an error, the selection fails with
+// ErrCodePermissionDenied and the field isn't resolved.
+func (field Field[T]) Authorize(fn AuthFunc) Field[T] {
+	if field.Spec.extend {
+		panic("cannot call on extended field")
+	}
+	field.Spec.authorize = fn
+	return field
+}
+
+// Timeout bounds the duration of each selection of the field. Selections
+// exceeding it have their context canceled, and fail with ErrCodeFieldTimeout
+// without failing the rest of the query.
+//
+// The timeout should be shorter than the server's query timeout, if any;
+// installing a field with a longer one logs a warning.
+func (field Field[T]) Timeout(d time.Duration) Field[T] {
+	if field.Spec.extend {
+		panic("cannot call on extended field")
+	}
+	field.Spec.timeout = d
+	return field
+}
+
 // Doc sets the description of the field. Each argument is joined by two empty
 // lines.
 func (field Field[T]) Doc(paras ...string) Field[T] {
@@ -1336,6 +1744,15 @@ func (field Field[T]) Args(args ...Argument) Field[T] {
 			panic(fmt.Sprintf("argument %q not found", patch.Spec.Name))
 		}
 		arg.merge(&patch.Spec)
+		if patch.Spec.Default != nil {
+			// coerce the default to the argument's type, e.g. to allow a String
+			// default for an Optional[String] argument
+			def, err := arg.Type.Decoder().DecodeInput(patch.Spec.Default.ToLiteral().ToInput())
+			if err != nil {
+				panic(fmt.Sprintf("argument %q: invalid default: %s", patch.Spec.Name, err))
+			}
+			arg.Default = def
+		}
 		newArgs = append(newArgs, arg)
 		patched[patch.Spec.Name] = struct{}{}
 	}
@@ -1353,6 +1770,16 @@ func (field Field[T]) Args(args ...Argument) Field[T] {
 	return field
 }
 
+// ArgSpecs returns a copy of the specs of the field's arguments in the given
+// view, e.g. to inspect them before the field is installed.
+func (field Field[T]) ArgSpecs(view call.View) []InputSpec {
+	specs := field.Spec.Args.Inputs(view)
+	for i := range specs {
+		specs[i].Directives = slices.Clone(specs[i].Directives)
+	}
+	return specs
+}
+
 func FormatDescription(paras ...string) string {
 	for i, p := range paras {
 		paras[i] = strings.Join(strings.Fields(strings.TrimSpace(p)), " ")
@@ -1367,10 +1794,15 @@ func (field Field[T]) Deprecated(paras ...string) Field[T] {
 		panic("cannot call on extended field")
 	}
 	field.Spec.DeprecatedReason = FormatDescription(paras...)
+	if field.Spec.DeprecatedReason == "" {
+		// the field has to carry a reason for the directive to be emitted
+		field.Spec.DeprecatedReason = defaultDeprecationReason
+	}
 	return field
 }
 
-// Deprecated marks the field as experimental
+// Experimental marks the field as experimental, with the @experimental
+// directive. Selecting it logs a warning.
 func (field Field[T]) Experimental(paras ...string) Field[T] {
 	if field.Spec.extend {
 		panic("cannot call on extended field")
@@ -1379,6 +1811,37 @@ func (field Field[T]) Experimental(paras ...string) Field[T] {
 	return field
 }
 
+// Optional marks the field's return type as nullable, so that returning a nil
+// value results in null without having to return a Nullable.
+func (field Field[T]) Optional() Field[T] {
+	if field.Spec.extend {
+		panic("cannot call on extended field")
+	}
+	elem := field.Spec.Type
+	field.Spec.Type = DynamicNullable{Elem: elem}
+	fn := field.Func
+	field.Func = func(ctx context.Context, self ObjectResult[T], args map[string]Input, view call.View) (AnyResult, error) {
+		res, err := fn(ctx, self, args, view)
+		if err != nil {
+			return nil, err
+		}
+		if res != nil && !isNilTyped(res.Unwrap()) {
+			return res, nil
+		}
+		return NewResultForCurrentID(ctx, DynamicNullable{Elem: elem})
+	}
+	return field
+}
+
+// isNilTyped returns true if the value is nil or a nil pointer.
+func isNilTyped(val Typed) bool {
+	if val == nil {
+		return true
+	}
+	rv := reflect.ValueOf(val)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
 // FieldDefinition returns the schema definition of the field.
 func (field Field[T]) FieldDefinition(view call.View) *ast.FieldDefinition {
 	if field.Spec.Type == nil {
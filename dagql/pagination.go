@@ -0,0 +1,380 @@
+package dagql
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// Sliceable adds optional `offset`, `first`, and `last` arguments to a list
+// field, which slice the list returned by the field's implementation:
+// `offset` skips elements from the start, then `first` keeps only the first n
+// elements and `last` only the last n.
+//
+// The list is sliced when the field is called rather than when it's resolved,
+// so that the field's ID, which includes the arguments, refers to the sliced
+// list.
+func (field Field[T]) Sliceable() Field[T] {
+	if field.Spec.extend {
+		panic("cannot call on extended field")
+	}
+	if typ := field.Spec.Type.Type(); typ.Elem == nil || !typ.NonNull {
+		panic(fmt.Sprintf("field %q: only non-null list fields can be sliced", field.Spec.Name))
+	}
+	args := slices.Clone(field.Spec.Args.raw)
+	for _, arg := range []InputSpec{
+		{Name: "offset", Description: "Skip this many elements from the start of the list."},
+		{Name: "first", Description: "Return at most this many elements from the start of the list."},
+		{Name: "last", Description: "Return at most this many elements from the end of the list."},
+	} {
+		if _, ok := field.Spec.Args.Input(arg.Name, ""); ok {
+			panic(fmt.Sprintf("field %q: argument %q is already defined", field.Spec.Name, arg.Name))
+		}
+		arg.Type = Optional[Int]{}
+		args = append(args, arg)
+	}
+	field.Spec.Args = InputSpecs{args}
+
+	fn := field.Func
+	field.Func = func(ctx context.Context, self ObjectResult[T], args map[string]Input, view call.View) (AnyResult, error) {
+		res, err := fn(ctx, self, args, view)
+		if err != nil || res == nil {
+			return res, err
+		}
+		return sliceResult(res, args)
+	}
+	return field
+}
+
+// sliceResult slices a list result according to the arguments added by
+// Sliceable.
+func sliceResult(res AnyResult, args map[string]Input) (AnyResult, error) {
+	enum, ok := res.Unwrap().(Enumerable)
+	if !ok {
+		return nil, fmt.Errorf("cannot slice %T", res.Unwrap())
+	}
+	from, to := 0, enum.Len()
+	if offset, ok, err := sliceArg(args, "offset"); err != nil {
+		return nil, err
+	} else if ok {
+		from = min(offset, to)
+	}
+	if first, ok, err := sliceArg(args, "first"); err != nil {
+		return nil, err
+	} else if ok {
+		to = min(from+first, to)
+	}
+	if last, ok, err := sliceArg(args, "last"); err != nil {
+		return nil, err
+	} else if ok {
+		from = max(to-last, from)
+	}
+	if from == 0 && to == enum.Len() {
+		return res, nil
+	}
+	sliced, err := enum.Slice(from, to)
+	if err != nil {
+		return nil, err
+	}
+	typed, ok := sliced.(Typed)
+	if !ok {
+		return nil, fmt.Errorf("slice of %T is not typed: %T", enum, sliced)
+	}
+	return NewResultForID(typed, res.ID())
+}
+
+// sliceArg returns the value of the named slicing argument, if it's set.
+func sliceArg(args map[string]Input, name string) (int, bool, error) {
+	arg, ok := args[name].(Optional[Int])
+	if !ok || !arg.Valid {
+		return 0, false, nil
+	}
+	n := arg.Value.Int()
+	if n < 0 {
+		return 0, false, fmt.Errorf("%s must not be negative, got %d", name, n)
+	}
+	return n, true, nil
+}
+
+// Paginated turns a list field into a paginated field following the Relay
+// cursor connections specification: optional `first` and `after` arguments
+// are added, and the list returned by the field's implementation is wrapped in
+// a connection, e.g. a list of `Foo` objects becomes a `FooConnection` with
+// `edges`, `pageInfo`, and `totalCount` fields.
+//
+// The connection types are installed along with the field by Fields.Install.
+func (field Field[T]) Paginated() Field[T] {
+	if field.Spec.extend {
+		panic("cannot call on extended field")
+	}
+	list, ok := field.Spec.Type.(Enumerable)
+	if !ok || !field.Spec.Type.Type().NonNull {
+		panic(fmt.Sprintf("field %q: only non-null list fields can be paginated", field.Spec.Name))
+	}
+	elem := list.Element()
+	if elem.Type().Name() == "" {
+		panic(fmt.Sprintf("field %q: cannot paginate lists of lists", field.Spec.Name))
+	}
+	args := slices.Clone(field.Spec.Args.raw)
+	for _, arg := range []InputSpec{
+		{Name: "first", Description: "Return at most this many edges.", Type: Optional[Int]{}},
+		{Name: "after", Description: "Return the edges after this cursor.", Type: Optional[String]{}},
+	} {
+		if _, ok := field.Spec.Args.Input(arg.Name, ""); ok {
+			panic(fmt.Sprintf("field %q: argument %q is already defined", field.Spec.Name, arg.Name))
+		}
+		args = append(args, arg)
+	}
+	field.Spec.Args = InputSpecs{args}
+	field.Spec.Type = &Connection{Elem: elem}
+	field.Spec.installTypes = func(srv *Server) {
+		installConnection(srv, elem)
+	}
+
+	fn := field.Func
+	field.Func = func(ctx context.Context, self ObjectResult[T], args map[string]Input, view call.View) (AnyResult, error) {
+		res, err := fn(ctx, self, args, view)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := paginate(elem, res, args)
+		if err != nil {
+			return nil, err
+		}
+		return NewResultForCurrentID(ctx, conn)
+	}
+	return field
+}
+
+// paginate returns the page of a list result selected by the arguments added
+// by Paginated.
+func paginate(elem Typed, res AnyResult, args map[string]Input) (*Connection, error) {
+	conn := &Connection{
+		Elem:     elem,
+		PageInfo: &PageInfo{},
+	}
+	if res == nil {
+		return conn, nil
+	}
+	enum, ok := res.Unwrap().(Enumerable)
+	if !ok {
+		return nil, fmt.Errorf("cannot paginate %T", res.Unwrap())
+	}
+	conn.TotalCount = enum.Len()
+
+	from, to := 0, enum.Len()
+	if after, ok := args["after"].(Optional[String]); ok && after.Valid {
+		offset, err := decodeCursor(after.Value.String())
+		if err != nil {
+			return nil, err
+		}
+		from = min(offset+1, to)
+	}
+	if first, ok := args["first"].(Optional[Int]); ok && first.Valid {
+		n := first.Value.Int()
+		if n < 0 {
+			return nil, fmt.Errorf("first must not be negative, got %d", n)
+		}
+		to = min(from+n, to)
+	}
+
+	results, hasResults := enum.(resultList)
+	for i := from; i < to; i++ {
+		node, err := enum.Nth(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		edge := &Edge{
+			Elem:   elem,
+			Node:   node,
+			Cursor: encodeCursor(i),
+		}
+		if hasResults {
+			edge.Result, err = results.nthResult(i + 1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		conn.Edges = append(conn.Edges, edge)
+	}
+	conn.PageInfo.HasPreviousPage = from > 0
+	conn.PageInfo.HasNextPage = to < enum.Len()
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.StartCursor = Opt(NewString(conn.Edges[0].Cursor))
+		conn.PageInfo.EndCursor = Opt(NewString(conn.Edges[len(conn.Edges)-1].Cursor))
+	}
+	return conn, nil
+}
+
+// resultList is implemented by lists whose elements are results with their own
+// IDs, rather than values identified by their position in the list.
+type resultList interface {
+	nthResult(i int) (AnyResult, error)
+}
+
+const cursorPrefix = "cursor:"
+
+// encodeCursor returns the opaque cursor of the element at the given index.
+func encodeCursor(i int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(i)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	dec, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	i, err := strconv.Atoi(strings.TrimPrefix(string(dec), cursorPrefix))
+	if err != nil || !strings.HasPrefix(string(dec), cursorPrefix) || i < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return i, nil
+}
+
+// Connection is a page of a paginated list of Elem values.
+type Connection struct {
+	Elem       Typed
+	Edges      []*Edge
+	PageInfo   *PageInfo
+	TotalCount int
+}
+
+func (conn *Connection) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: conn.Elem.Type().Name() + "Connection",
+		NonNull:   true,
+	}
+}
+
+func (conn *Connection) TypeDescription() string {
+	return fmt.Sprintf("A page of a paginated list of %s values.", conn.Elem.Type().Name())
+}
+
+// Edge is an element of a Connection, along with its cursor.
+type Edge struct {
+	Elem Typed
+	Node Typed
+	// Result is the element's own result, if the list held results rather
+	// than bare values. Its node is then the result, keeping its ID.
+	Result AnyResult
+	Cursor string
+}
+
+func (edge *Edge) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: edge.Elem.Type().Name() + "Edge",
+		NonNull:   true,
+	}
+}
+
+func (edge *Edge) TypeDescription() string {
+	return fmt.Sprintf("A %s in a paginated list, along with its cursor.", edge.Elem.Type().Name())
+}
+
+// PageInfo describes the page of a Connection.
+type PageInfo struct {
+	HasNextPage     bool             `field:"true" doc:"Whether there are more edges after this page."`
+	HasPreviousPage bool             `field:"true" doc:"Whether there are more edges before this page."`
+	StartCursor     Optional[String] `field:"true" doc:"The cursor of the first edge of this page."`
+	EndCursor       Optional[String] `field:"true" doc:"The cursor of the last edge of this page."`
+}
+
+func (*PageInfo) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "PageInfo",
+		NonNull:   true,
+	}
+}
+
+func (*PageInfo) TypeDescription() string {
+	return "Information about a page of a paginated list."
+}
+
+// installConnection installs the connection and edge types for lists of elem,
+// and the PageInfo type, unless they're already installed.
+func installConnection(srv *Server, elem Typed) {
+	if _, ok := srv.ObjectType((&PageInfo{}).Type().Name()); !ok {
+		srv.InstallObject(NewClass(srv, ClassOpts[*PageInfo]{NoIDs: true}))
+		Fields[*PageInfo]{}.Install(srv)
+	}
+
+	edgeType := &Edge{Elem: elem}
+	if _, ok := srv.ObjectType(edgeType.Type().Name()); !ok {
+		edges := NewClass(srv, ClassOpts[*Edge]{NoIDs: true, Typed: edgeType})
+		edges.Install(
+			Field[*Edge]{
+				Spec: &FieldSpec{
+					Name:        "node",
+					Description: "The element of the list.",
+					Type:        elem,
+				},
+				Func: func(ctx context.Context, self ObjectResult[*Edge], _ map[string]Input, _ call.View) (AnyResult, error) {
+					if res := self.Self().Result; res != nil {
+						return res, nil
+					}
+					return NewResultForCurrentID(ctx, self.Self().Node)
+				},
+			},
+			Field[*Edge]{
+				Spec: &FieldSpec{
+					Name:        "cursor",
+					Description: "The cursor of the element, to pass as `after` to get the elements after it.",
+					Type:        String(""),
+				},
+				Func: func(ctx context.Context, self ObjectResult[*Edge], _ map[string]Input, _ call.View) (AnyResult, error) {
+					return NewResultForCurrentID(ctx, NewString(self.Self().Cursor))
+				},
+			},
+		)
+		srv.InstallObject(edges)
+	}
+
+	connType := &Connection{Elem: elem}
+	if _, ok := srv.ObjectType(connType.Type().Name()); !ok {
+		conns := NewClass(srv, ClassOpts[*Connection]{NoIDs: true, Typed: connType})
+		conns.Install(
+			Field[*Connection]{
+				Spec: &FieldSpec{
+					Name:        "edges",
+					Description: "The edges of this page.",
+					Type:        DynamicArrayOutput{Elem: edgeType},
+				},
+				Func: func(ctx context.Context, self ObjectResult[*Connection], _ map[string]Input, _ call.View) (AnyResult, error) {
+					edges := DynamicArrayOutput{Elem: edgeType}
+					for _, edge := range self.Self().Edges {
+						edges.Values = append(edges.Values, edge)
+					}
+					return NewResultForCurrentID(ctx, edges)
+				},
+			},
+			Field[*Connection]{
+				Spec: &FieldSpec{
+					Name:        "pageInfo",
+					Description: "Information about this page.",
+					Type:        &PageInfo{},
+				},
+				Func: func(ctx context.Context, self ObjectResult[*Connection], _ map[string]Input, _ call.View) (AnyResult, error) {
+					return NewResultForCurrentID(ctx, self.Self().PageInfo)
+				},
+			},
+			Field[*Connection]{
+				Spec: &FieldSpec{
+					Name:        "totalCount",
+					Description: "The number of elements in the whole list.",
+					Type:        Int(0),
+				},
+				Func: func(ctx context.Context, self ObjectResult[*Connection], _ map[string]Input, _ call.View) (AnyResult, error) {
+					return NewResultForCurrentID(ctx, NewInt(self.Self().TotalCount))
+				},
+			},
+		)
+		srv.InstallObject(conns)
+	}
+}
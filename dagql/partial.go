@@ -0,0 +1,69 @@
+package dagql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// fieldErrors collects the errors of nullable fields that failed while
+// executing a request, which are reported alongside the partial results
+// instead of failing the entire request.
+type fieldErrors struct {
+	mu   sync.Mutex
+	errs gqlerror.List
+}
+
+func (fe *fieldErrors) add(err error) {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	fe.errs = append(fe.errs, gqlErrs(err)...)
+}
+
+func (fe *fieldErrors) list() gqlerror.List {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	return fe.errs
+}
+
+type fieldErrorsCtx struct{}
+
+func fieldErrorsToContext(ctx context.Context, fe *fieldErrors) context.Context {
+	return context.WithValue(ctx, fieldErrorsCtx{}, fe)
+}
+
+func fieldErrorsFromContext(ctx context.Context) *fieldErrors {
+	fe, _ := ctx.Value(fieldErrorsCtx{}).(*fieldErrors)
+	return fe
+}
+
+// resolveNullable resolves a selection like resolvePath, except that a failed
+// nullable field resolves to null and its error is recorded instead, if the
+// request allows partial results.
+//
+// Errors from non-null fields are returned as usual, failing the parent
+// selection in turn.
+func (s *Server) resolveNullable(ctx context.Context, self AnyObjectResult, sel Selection) (any, error) {
+	res, err := s.resolvePath(ctx, self, sel)
+	if err == nil {
+		return res, nil
+	}
+	fe := fieldErrorsFromContext(ctx)
+	if fe == nil || !isNullableSelection(self, sel) {
+		return nil, err
+	}
+	fe.add(err)
+	return nil, nil
+}
+
+func isNullableSelection(self AnyObjectResult, sel Selection) bool {
+	if sel.Selector.Field == typenameField {
+		return false
+	}
+	spec, ok := self.ObjectType().FieldSpec(sel.Selector.Field, sel.Selector.View)
+	if !ok {
+		return false
+	}
+	return !spec.Type.Type().NonNull
+}
@@ -0,0 +1,41 @@
+package dagql
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// PersistedQueryStore stores query documents by the hex-encoded SHA-256 hash
+// of their contents, for clients using automatic persisted queries (APQ).
+//
+// Implementations must be safe for concurrent use.
+type PersistedQueryStore interface {
+	Get(hash string) (string, bool)
+	Set(hash, query string)
+}
+
+// UsePersistedQueries configures the server to store the queries it receives
+// in the given store, so that later requests may refer to them by hash alone
+// via the `persistedQuery` extension.
+//
+// This is only effective for handlers created with NewDefaultHandler.
+func (s *Server) UsePersistedQueries(store PersistedQueryStore) {
+	s.persistedQueries = store
+}
+
+// persistedQueryCache adapts a PersistedQueryStore to the cache used by
+// gqlgen's APQ extension.
+type persistedQueryCache struct {
+	store PersistedQueryStore
+}
+
+var _ graphql.Cache[string] = persistedQueryCache{}
+
+func (c persistedQueryCache) Get(_ context.Context, hash string) (string, bool) {
+	return c.store.Get(hash)
+}
+
+func (c persistedQueryCache) Add(_ context.Context, hash string, query string) {
+	c.store.Set(hash, query)
+}
@@ -0,0 +1,52 @@
+package dagql
+
+import (
+	"maps"
+	"slices"
+)
+
+// handlerSet holds the functions registered on a server to be called while
+// queries are resolved. They're looked up for every field, so rather than
+// taking installLock, resolution reads an immutable set that registering a
+// function replaces with an updated copy.
+type handlerSet struct {
+	middlewares       []FieldMiddleware
+	execMiddlewares   []ExecMiddleware
+	hooks             map[Event][]HookFunc
+	objectHooks       map[string][]ObjectHook
+	panicHandlers     []PanicFunc
+	directiveHandlers map[string]DirectiveHandler
+	scalarEncoders    map[string]ScalarEncoder
+	scalarDecoders    map[string]ScalarDecoder
+}
+
+func newHandlerSet() *handlerSet {
+	return &handlerSet{
+		hooks:             map[Event][]HookFunc{},
+		objectHooks:       map[string][]ObjectHook{},
+		directiveHandlers: map[string]DirectiveHandler{},
+		scalarEncoders:    map[string]ScalarEncoder{},
+		scalarDecoders:    map[string]ScalarDecoder{},
+	}
+}
+
+// updateHandlers replaces the server's handlers with a copy modified by fn.
+func (s *Server) updateHandlers(fn func(*handlerSet)) {
+	s.installLock.Lock()
+	defer s.installLock.Unlock()
+	cur := s.handlers.Load()
+	// clip the slices so that appending to them doesn't write to the arrays
+	// of the current set, which may still be read
+	next := &handlerSet{
+		middlewares:       slices.Clip(cur.middlewares),
+		execMiddlewares:   slices.Clip(cur.execMiddlewares),
+		hooks:             maps.Clone(cur.hooks),
+		objectHooks:       maps.Clone(cur.objectHooks),
+		panicHandlers:     slices.Clip(cur.panicHandlers),
+		directiveHandlers: maps.Clone(cur.directiveHandlers),
+		scalarEncoders:    maps.Clone(cur.scalarEncoders),
+		scalarDecoders:    maps.Clone(cur.scalarDecoders),
+	}
+	fn(next)
+	s.handlers.Store(next)
+}
@@ -0,0 +1,63 @@
+package dagql
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// RequestMetadata describes the request that a query is resolved for.
+//
+// ServeHTTP and NewDefaultHandler set it in the context passed to resolvers,
+// where it can be retrieved with CurrentRequestMetadata. Callers executing
+// queries by other means may set it with ContextWithRequestMetadata. Results
+// are cached across requests, so fields that depend on it should not be cached.
+type RequestMetadata struct {
+	// RequestID is the value of the X-Request-Id header, if any.
+	RequestID string
+	// RemoteAddr is the network address of the client, if known.
+	RemoteAddr string
+	// AuthToken is the bearer token of the Authorization header, if any.
+	AuthToken string
+}
+
+func requestMetadataFromHeaders(headers http.Header) *RequestMetadata {
+	md := &RequestMetadata{
+		RequestID: headers.Get("X-Request-Id"),
+	}
+	if scheme, token, ok := strings.Cut(headers.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		md.AuthToken = strings.TrimSpace(token)
+	}
+	return md
+}
+
+type requestMetadataCtx struct{}
+
+// ContextWithRequestMetadata returns a context carrying the given request
+// metadata.
+func ContextWithRequestMetadata(ctx context.Context, md *RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataCtx{}, md)
+}
+
+// CurrentRequestMetadata returns the metadata of the request being resolved.
+func CurrentRequestMetadata(ctx context.Context) (*RequestMetadata, bool) {
+	return FromContext[*RequestMetadata](ctx, requestMetadataCtx{})
+}
+
+// FromContext returns the value set in the context for the given key, if any
+// and if it has type T.
+func FromContext[T any](ctx context.Context, key any) (T, bool) {
+	val, ok := ctx.Value(key).(T)
+	return val, ok
+}
+
+// requestMetadataOperation sets the request metadata of operations handled by
+// a gqlgen handler, unless the context already carries some.
+func requestMetadataOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	if _, ok := CurrentRequestMetadata(ctx); !ok {
+		ctx = ContextWithRequestMetadata(ctx, requestMetadataFromHeaders(graphql.GetOperationContext(ctx).Headers))
+	}
+	return next(ctx)
+}
@@ -0,0 +1,93 @@
+package dagql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ScalarEncoder returns the JSON representation of a scalar value in
+// responses.
+type ScalarEncoder func(Typed) ([]byte, error)
+
+// ScalarDecoder decodes a scalar value from the JSON representation of an
+// argument value.
+type ScalarDecoder func([]byte) (Input, error)
+
+// RegisterScalarEncoder sets the encoder for values of the named scalar type
+// returned in responses, overriding their default JSON marshaling.
+func (s *Server) RegisterScalarEncoder(typeName string, enc ScalarEncoder) {
+	s.updateHandlers(func(h *handlerSet) {
+		h.scalarEncoders[typeName] = enc
+	})
+}
+
+// RegisterScalarDecoder sets the decoder for arguments of the named scalar
+// type, overriding the decoder of the argument's type. Both required and
+// optional arguments are decoded this way; null values of optional arguments
+// aren't passed to the decoder.
+func (s *Server) RegisterScalarDecoder(typeName string, dec ScalarDecoder) {
+	s.updateHandlers(func(h *handlerSet) {
+		h.scalarDecoders[typeName] = dec
+	})
+}
+
+// encodeLeaf returns the value to marshal into the response for a leaf value.
+func (s *Server) encodeLeaf(val Typed) (any, error) {
+	if val == nil {
+		return nil, nil
+	}
+	enc, ok := s.handlers.Load().scalarEncoders[val.Type().Name()]
+	if !ok {
+		return val, nil
+	}
+	data, err := enc(val)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", val.Type().Name(), err)
+	}
+	return json.RawMessage(data), nil
+}
+
+// decodeArg decodes the value of an argument, using the decoder registered for
+// its type on the server in ctx, if any.
+func decodeArg(ctx context.Context, spec InputSpec, val any) (Input, error) {
+	if dec, ok := argDecoder(ctx, spec); ok && val != nil {
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		input, err := dec(data)
+		if err != nil {
+			return nil, err
+		}
+		if opt, ok := spec.Type.(optionalInput); ok {
+			return opt.withValue(input)
+		}
+		return input, nil
+	}
+	return spec.Type.Decoder().DecodeInput(val)
+}
+
+// optionalInput is an optional argument type, that wraps values decoded for
+// its element type.
+type optionalInput interface {
+	withValue(Input) (Input, error)
+}
+
+// argDecoder returns the decoder registered for the type of the argument, if
+// it's a scalar that is either non-null or optional.
+func argDecoder(ctx context.Context, spec InputSpec) (ScalarDecoder, bool) {
+	typ := spec.Type.Type()
+	if typ.Elem != nil {
+		return nil, false
+	}
+	if _, isOpt := spec.Type.(optionalInput); !typ.NonNull && !isOpt {
+		return nil, false
+	}
+	srv := CurrentDagqlServer(ctx)
+	if srv == nil {
+		return nil, false
+	}
+	dec, ok := srv.handlers.Load().scalarDecoders[typ.Name()]
+	return dec, ok
+}
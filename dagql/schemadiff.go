@@ -0,0 +1,264 @@
+package dagql
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SchemaChangeKind identifies the kind of a schema change, so that tooling can
+// act on changes without parsing their messages.
+type SchemaChangeKind string
+
+const (
+	TypeAdded             SchemaChangeKind = "TYPE_ADDED"
+	TypeRemoved           SchemaChangeKind = "TYPE_REMOVED"
+	TypeKindChanged       SchemaChangeKind = "TYPE_KIND_CHANGED"
+	FieldAdded            SchemaChangeKind = "FIELD_ADDED"
+	FieldRemoved          SchemaChangeKind = "FIELD_REMOVED"
+	FieldTypeChanged      SchemaChangeKind = "FIELD_TYPE_CHANGED"
+	ArgumentAdded         SchemaChangeKind = "ARGUMENT_ADDED"
+	ArgumentRemoved       SchemaChangeKind = "ARGUMENT_REMOVED"
+	ArgumentTypeChanged   SchemaChangeKind = "ARGUMENT_TYPE_CHANGED"
+	InputFieldAdded       SchemaChangeKind = "INPUT_FIELD_ADDED"
+	InputFieldRemoved     SchemaChangeKind = "INPUT_FIELD_REMOVED"
+	InputFieldTypeChanged SchemaChangeKind = "INPUT_FIELD_TYPE_CHANGED"
+	EnumValueAdded        SchemaChangeKind = "ENUM_VALUE_ADDED"
+	EnumValueRemoved      SchemaChangeKind = "ENUM_VALUE_REMOVED"
+	InterfaceAdded        SchemaChangeKind = "INTERFACE_ADDED"
+	InterfaceRemoved      SchemaChangeKind = "INTERFACE_REMOVED"
+	UnionMemberAdded      SchemaChangeKind = "UNION_MEMBER_ADDED"
+	UnionMemberRemoved    SchemaChangeKind = "UNION_MEMBER_REMOVED"
+	DirectiveAdded        SchemaChangeKind = "DIRECTIVE_ADDED"
+	DirectiveRemoved      SchemaChangeKind = "DIRECTIVE_REMOVED"
+)
+
+// SchemaChange describes a single difference between two schemas.
+type SchemaChange struct {
+	// Kind identifies the kind of change.
+	Kind SchemaChangeKind
+	// Path is the coordinate of the changed element, e.g. `Type`,
+	// `Type.field`, or `Type.field(arg:)`.
+	Path string
+	// Message describes the change.
+	Message string
+}
+
+func (change SchemaChange) String() string {
+	return change.Path + ": " + change.Message
+}
+
+// BreakingChange is a schema change that may break existing callers, e.g. a
+// removed field or a changed return type.
+type BreakingChange struct {
+	SchemaChange
+}
+
+// NonBreakingChange is a schema change that is backwards compatible with
+// existing callers, e.g. an added type or optional argument.
+type NonBreakingChange struct {
+	SchemaChange
+}
+
+// SchemaDiff compares two schemas and classifies their differences into
+// breaking and non-breaking changes, sorted by path.
+func SchemaDiff(oldSchema, newSchema *ast.Schema) ([]BreakingChange, []NonBreakingChange, error) {
+	if oldSchema == nil || newSchema == nil {
+		return nil, nil, errors.New("cannot diff nil schema")
+	}
+	var diff schemaDiff
+	for _, name := range slices.Sorted(maps.Keys(oldSchema.Types)) {
+		oldDef := oldSchema.Types[name]
+		newDef, ok := newSchema.Types[name]
+		if !ok {
+			diff.breaking(TypeRemoved, name, "type %s was removed", oldDef.Kind)
+			continue
+		}
+		if oldDef.Kind != newDef.Kind {
+			diff.breaking(TypeKindChanged, name, "kind changed from %s to %s", oldDef.Kind, newDef.Kind)
+			continue
+		}
+		diff.definition(oldDef, newDef)
+	}
+	for _, name := range slices.Sorted(maps.Keys(newSchema.Types)) {
+		if _, ok := oldSchema.Types[name]; !ok {
+			diff.nonBreaking(TypeAdded, name, "type %s was added", newSchema.Types[name].Kind)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(oldSchema.Directives)) {
+		if _, ok := newSchema.Directives[name]; !ok {
+			diff.breaking(DirectiveRemoved, "@"+name, "directive was removed")
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(newSchema.Directives)) {
+		if _, ok := oldSchema.Directives[name]; !ok {
+			diff.nonBreaking(DirectiveAdded, "@"+name, "directive was added")
+		}
+	}
+	sort.SliceStable(diff.breakingChanges, func(i, j int) bool {
+		return diff.breakingChanges[i].Path < diff.breakingChanges[j].Path
+	})
+	sort.SliceStable(diff.nonBreakingChanges, func(i, j int) bool {
+		return diff.nonBreakingChanges[i].Path < diff.nonBreakingChanges[j].Path
+	})
+	return diff.breakingChanges, diff.nonBreakingChanges, nil
+}
+
+type schemaDiff struct {
+	breakingChanges    []BreakingChange
+	nonBreakingChanges []NonBreakingChange
+}
+
+func (diff *schemaDiff) breaking(kind SchemaChangeKind, path string, msg string, args ...any) {
+	diff.breakingChanges = append(diff.breakingChanges, BreakingChange{SchemaChange{
+		Kind:    kind,
+		Path:    path,
+		Message: fmt.Sprintf(msg, args...),
+	}})
+}
+
+func (diff *schemaDiff) nonBreaking(kind SchemaChangeKind, path string, msg string, args ...any) {
+	diff.nonBreakingChanges = append(diff.nonBreakingChanges, NonBreakingChange{SchemaChange{
+		Kind:    kind,
+		Path:    path,
+		Message: fmt.Sprintf(msg, args...),
+	}})
+}
+
+func (diff *schemaDiff) definition(oldDef, newDef *ast.Definition) {
+	switch oldDef.Kind {
+	case ast.Object, ast.Interface:
+		diff.outputFields(oldDef, newDef)
+		diff.members(oldDef.Name, "interface", InterfaceRemoved, InterfaceAdded, oldDef.Interfaces, newDef.Interfaces)
+	case ast.InputObject:
+		diff.inputFields(oldDef, newDef)
+	case ast.Union:
+		diff.members(oldDef.Name, "member", UnionMemberRemoved, UnionMemberAdded, oldDef.Types, newDef.Types)
+	case ast.Enum:
+		for _, val := range oldDef.EnumValues {
+			if newDef.EnumValues.ForName(val.Name) == nil {
+				diff.breaking(EnumValueRemoved, oldDef.Name+"."+val.Name, "enum value was removed")
+			}
+		}
+		for _, val := range newDef.EnumValues {
+			if oldDef.EnumValues.ForName(val.Name) == nil {
+				diff.nonBreaking(EnumValueAdded, oldDef.Name+"."+val.Name, "enum value was added")
+			}
+		}
+	}
+}
+
+func (diff *schemaDiff) outputFields(oldDef, newDef *ast.Definition) {
+	for _, oldField := range oldDef.Fields {
+		path := oldDef.Name + "." + oldField.Name
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			diff.breaking(FieldRemoved, path, "field was removed")
+			continue
+		}
+		if oldType, newType := oldField.Type.String(), newField.Type.String(); oldType != newType {
+			if isStricter(newField.Type, oldField.Type) {
+				diff.nonBreaking(FieldTypeChanged, path, "return type changed from %s to %s", oldType, newType)
+			} else {
+				diff.breaking(FieldTypeChanged, path, "return type changed from %s to %s", oldType, newType)
+			}
+		}
+		diff.arguments(path, oldField.Arguments, newField.Arguments)
+	}
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) == nil {
+			diff.nonBreaking(FieldAdded, oldDef.Name+"."+newField.Name, "field was added")
+		}
+	}
+}
+
+func (diff *schemaDiff) arguments(fieldPath string, oldArgs, newArgs ast.ArgumentDefinitionList) {
+	for _, oldArg := range oldArgs {
+		path := fieldPath + "(" + oldArg.Name + ":)"
+		newArg := newArgs.ForName(oldArg.Name)
+		if newArg == nil {
+			diff.breaking(ArgumentRemoved, path, "argument was removed")
+			continue
+		}
+		diff.inputType(ArgumentTypeChanged, path, "argument", oldArg.Type, newArg.Type)
+	}
+	for _, newArg := range newArgs {
+		if oldArgs.ForName(newArg.Name) != nil {
+			continue
+		}
+		diff.addedInput(ArgumentAdded, fieldPath+"("+newArg.Name+":)", "argument", newArg.Type, newArg.DefaultValue)
+	}
+}
+
+func (diff *schemaDiff) inputFields(oldDef, newDef *ast.Definition) {
+	for _, oldField := range oldDef.Fields {
+		path := oldDef.Name + "." + oldField.Name
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			diff.breaking(InputFieldRemoved, path, "input field was removed")
+			continue
+		}
+		diff.inputType(InputFieldTypeChanged, path, "input field", oldField.Type, newField.Type)
+	}
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) != nil {
+			continue
+		}
+		diff.addedInput(InputFieldAdded, oldDef.Name+"."+newField.Name, "input field", newField.Type, newField.DefaultValue)
+	}
+}
+
+func (diff *schemaDiff) inputType(kind SchemaChangeKind, path, what string, oldType, newType *ast.Type) {
+	if oldType.String() == newType.String() {
+		return
+	}
+	// inputs may safely become optional, but not required or a different type
+	if isStricter(oldType, newType) {
+		diff.nonBreaking(kind, path, "%s type changed from %s to %s", what, oldType, newType)
+	} else {
+		diff.breaking(kind, path, "%s type changed from %s to %s", what, oldType, newType)
+	}
+}
+
+func (diff *schemaDiff) addedInput(kind SchemaChangeKind, path, what string, typ *ast.Type, defaultValue *ast.Value) {
+	if typ.NonNull && defaultValue == nil {
+		diff.breaking(kind, path, "required %s was added", what)
+	} else {
+		diff.nonBreaking(kind, path, "optional %s was added", what)
+	}
+}
+
+func (diff *schemaDiff) members(path, what string, removed, added SchemaChangeKind, oldNames, newNames []string) {
+	for _, name := range oldNames {
+		if !slices.Contains(newNames, name) {
+			diff.breaking(removed, path, "%s %s was removed", what, name)
+		}
+	}
+	for _, name := range newNames {
+		if !slices.Contains(oldNames, name) {
+			diff.nonBreaking(added, path, "%s %s was added", what, name)
+		}
+	}
+}
+
+// isStricter returns true if the strict type is the same as the loose type,
+// except for being non-null where the loose type is nullable.
+func isStricter(strict, loose *ast.Type) bool {
+	if strict == nil || loose == nil {
+		return false
+	}
+	if loose.NonNull && !strict.NonNull {
+		return false
+	}
+	if strict.NamedType != loose.NamedType || (strict.Elem == nil) != (loose.Elem == nil) {
+		return false
+	}
+	if strict.Elem == nil {
+		return true
+	}
+	return isStricter(strict.Elem, loose.Elem)
+}
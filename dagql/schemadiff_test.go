@@ -0,0 +1,138 @@
+package dagql_test
+
+import (
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"gotest.tools/v3/assert"
+
+	"github.com/dagger/dagger/dagql"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	oldSchema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+type Query {
+	point(x: Int!, y: Int!): Point!
+	line: Line
+	removed: String
+}
+
+type Point {
+	x: Int!
+	y: Int
+	name(format: String): String
+}
+
+type Line {
+	from: Point!
+}
+
+input Opts {
+	a: String
+	b: Int!
+}
+
+enum Direction { UP DOWN }
+
+union Shape = Point | Line
+`})
+	newSchema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+type Query {
+	point(x: Int, y: Int!, z: Int!): Point!
+	line: Line!
+	added: String
+}
+
+type Point {
+	x: String!
+	y: Int!
+	name(format: Int, prefix: String): String
+}
+
+type Line {
+	from: Point!
+}
+
+type Circle {
+	radius: Int!
+}
+
+input Opts {
+	a: String!
+	c: Int = 1
+}
+
+enum Direction { UP LEFT }
+
+union Shape = Point | Circle
+`})
+
+	breaking, nonBreaking, err := dagql.SchemaDiff(oldSchema, newSchema)
+	assert.NilError(t, err)
+
+	var breakingStrs []string
+	for _, change := range breaking {
+		breakingStrs = append(breakingStrs, change.String())
+	}
+	assert.DeepEqual(t, []string{
+		"Direction.DOWN: enum value was removed",
+		"Opts.a: input field type changed from String to String!",
+		"Opts.b: input field was removed",
+		"Point.name(format:): argument type changed from String to Int",
+		"Point.x: return type changed from Int! to String!",
+		"Query.point(z:): required argument was added",
+		"Query.removed: field was removed",
+		"Shape: member Line was removed",
+	}, breakingStrs)
+
+	var nonBreakingStrs []string
+	for _, change := range nonBreaking {
+		nonBreakingStrs = append(nonBreakingStrs, change.String())
+	}
+	assert.DeepEqual(t, []string{
+		"Circle: type OBJECT was added",
+		"Direction.LEFT: enum value was added",
+		"Opts.c: optional input field was added",
+		"Point.name(prefix:): optional argument was added",
+		"Point.y: return type changed from Int to Int!",
+		"Query.added: field was added",
+		"Query.line: return type changed from Line to Line!",
+		"Query.point(x:): argument type changed from Int! to Int",
+		"Shape: member Circle was added",
+	}, nonBreakingStrs)
+
+	var breakingKinds []dagql.SchemaChangeKind
+	for _, change := range breaking {
+		breakingKinds = append(breakingKinds, change.Kind)
+	}
+	assert.DeepEqual(t, []dagql.SchemaChangeKind{
+		dagql.EnumValueRemoved,
+		dagql.InputFieldTypeChanged,
+		dagql.InputFieldRemoved,
+		dagql.ArgumentTypeChanged,
+		dagql.FieldTypeChanged,
+		dagql.ArgumentAdded,
+		dagql.FieldRemoved,
+		dagql.UnionMemberRemoved,
+	}, breakingKinds)
+
+	var nonBreakingKinds []dagql.SchemaChangeKind
+	for _, change := range nonBreaking {
+		nonBreakingKinds = append(nonBreakingKinds, change.Kind)
+	}
+	assert.DeepEqual(t, []dagql.SchemaChangeKind{
+		dagql.TypeAdded,
+		dagql.EnumValueAdded,
+		dagql.InputFieldAdded,
+		dagql.ArgumentAdded,
+		dagql.FieldTypeChanged,
+		dagql.FieldAdded,
+		dagql.FieldTypeChanged,
+		dagql.ArgumentTypeChanged,
+		dagql.UnionMemberAdded,
+	}, nonBreakingKinds)
+
+	_, _, err = dagql.SchemaDiff(nil, newSchema)
+	assert.ErrorContains(t, err, "cannot diff nil schema")
+}
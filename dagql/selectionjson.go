@@ -0,0 +1,233 @@
+package dagql
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// selectionJSON is the wire format of a Selection.
+type selectionJSON struct {
+	Alias string    `json:"alias,omitempty"`
+	Field string    `json:"field"`
+	Nth   int       `json:"nth,omitempty"`
+	View  call.View `json:"view,omitempty"`
+	// Args is an encoded ID whose only call carries the arguments of the
+	// selector, so that literals of any kind, including IDs, round-trip.
+	Args          string `json:"args,omitempty"`
+	TypeCondition string `json:"typeCondition,omitempty"`
+	// Subselections is null for leaf fields, and may otherwise be empty.
+	Subselections []selectionJSON        `json:"subselections"`
+	Directives    []appliedDirectiveJSON `json:"directives,omitempty"`
+}
+
+// appliedDirectiveJSON is the wire format of an AppliedDirective.
+type appliedDirectiveJSON struct {
+	Name string `json:"name"`
+	// Args is an encoded ID carrying the arguments, like those of a selector.
+	Args string `json:"args,omitempty"`
+}
+
+// SelectionsToJSON serializes selections, e.g. to resolve them elsewhere with
+// Server.Resolve after reading them back with SelectionsFromJSON.
+func SelectionsToJSON(sels []Selection) ([]byte, error) {
+	enc, err := selectionsToJSON(sels)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(enc)
+}
+
+// SelectionsFromJSON deserializes selections written by SelectionsToJSON.
+//
+// The types of the arguments aren't known until the selections are resolved,
+// so argument values are decoded against the schema when they're selected.
+func SelectionsFromJSON(data []byte) ([]Selection, error) {
+	var enc []selectionJSON
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, err
+	}
+	return selectionsFromJSON(enc)
+}
+
+func selectionsToJSON(sels []Selection) ([]selectionJSON, error) {
+	if sels == nil {
+		return nil, nil
+	}
+	enc := make([]selectionJSON, 0, len(sels))
+	for _, sel := range sels {
+		subsels, err := selectionsToJSON(sel.Subselections)
+		if err != nil {
+			return nil, err
+		}
+		var args string
+		if len(sel.Selector.Args) > 0 {
+			idArgs := make([]*call.Argument, len(sel.Selector.Args))
+			for i, arg := range sel.Selector.Args {
+				idArgs[i] = call.NewArgument(arg.Name, arg.Value.ToLiteral(), false)
+			}
+			args, err = encodeArgs(sel.Selector.Field, idArgs)
+			if err != nil {
+				return nil, fmt.Errorf("encode args of %q: %w", sel.Name(), err)
+			}
+		}
+		directives, err := directivesToJSON(sel.Directives)
+		if err != nil {
+			return nil, fmt.Errorf("encode directives of %q: %w", sel.Name(), err)
+		}
+		enc = append(enc, selectionJSON{
+			Alias:         sel.Alias,
+			Field:         sel.Selector.Field,
+			Nth:           sel.Selector.Nth,
+			View:          sel.Selector.View,
+			Args:          args,
+			TypeCondition: sel.TypeCondition,
+			Subselections: subsels,
+			Directives:    directives,
+		})
+	}
+	return enc, nil
+}
+
+func directivesToJSON(directives []AppliedDirective) ([]appliedDirectiveJSON, error) {
+	var enc []appliedDirectiveJSON
+	for _, directive := range directives {
+		var idArgs []*call.Argument
+		for _, name := range slices.Sorted(maps.Keys(directive.Args)) {
+			var lit call.Literal = call.NewLiteralNull()
+			if val := directive.Args[name]; val != nil {
+				var err error
+				lit, err = call.ToLiteral(val)
+				if err != nil {
+					return nil, fmt.Errorf("@%s: %w", directive.Name, err)
+				}
+			}
+			idArgs = append(idArgs, call.NewArgument(name, lit, false))
+		}
+		var args string
+		if len(idArgs) > 0 {
+			var err error
+			args, err = encodeArgs(directive.Name, idArgs)
+			if err != nil {
+				return nil, fmt.Errorf("@%s: %w", directive.Name, err)
+			}
+		}
+		enc = append(enc, appliedDirectiveJSON{
+			Name: directive.Name,
+			Args: args,
+		})
+	}
+	return enc, nil
+}
+
+// encodeArgs encodes arguments as an ID whose only call, named after what they
+// are the arguments of, carries them.
+func encodeArgs(name string, args []*call.Argument) (string, error) {
+	return call.New().Append(
+		&ast.Type{NamedType: name},
+		name,
+		"",
+		nil,
+		0,
+		"",
+		args...,
+	).Encode()
+}
+
+func selectionsFromJSON(enc []selectionJSON) ([]Selection, error) {
+	if enc == nil {
+		return nil, nil
+	}
+	sels := make([]Selection, 0, len(enc))
+	for _, sel := range enc {
+		subsels, err := selectionsFromJSON(sel.Subselections)
+		if err != nil {
+			return nil, err
+		}
+		var args []NamedInput
+		if sel.Args != "" {
+			var id call.ID
+			if err := id.Decode(sel.Args); err != nil {
+				return nil, fmt.Errorf("decode args of %q: %w", sel.Field, err)
+			}
+			for _, arg := range id.Args() {
+				args = append(args, NamedInput{
+					Name:  arg.Name(),
+					Value: literalInput{arg.Value()},
+				})
+			}
+		}
+		var directives []AppliedDirective
+		for _, directive := range sel.Directives {
+			args := map[string]any{}
+			if directive.Args != "" {
+				var id call.ID
+				if err := id.Decode(directive.Args); err != nil {
+					return nil, fmt.Errorf("decode args of @%s on %q: %w", directive.Name, sel.Field, err)
+				}
+				for _, arg := range id.Args() {
+					args[arg.Name()] = arg.Value().ToInput()
+				}
+			}
+			directives = append(directives, AppliedDirective{
+				Name: directive.Name,
+				Args: args,
+			})
+		}
+		sels = append(sels, Selection{
+			Alias: sel.Alias,
+			Selector: Selector{
+				Field: sel.Field,
+				Args:  args,
+				Nth:   sel.Nth,
+				View:  sel.View,
+			},
+			Subselections: subsels,
+			TypeCondition: sel.TypeCondition,
+			Directives:    directives,
+		})
+	}
+	return sels, nil
+}
+
+// literalInput is an argument value whose type isn't known yet. It is decoded
+// with the decoder of the argument's type once the field is selected.
+type literalInput struct {
+	lit call.Literal
+}
+
+var _ Input = literalInput{}
+
+func (input literalInput) Type() *ast.Type {
+	// not known until the argument is decoded
+	return &ast.Type{}
+}
+
+func (input literalInput) ToLiteral() call.Literal {
+	return input.lit
+}
+
+func (input literalInput) Decoder() InputDecoder {
+	return DecoderFunc(func(val any) (Input, error) {
+		lit, err := call.ToLiteral(val)
+		if err != nil {
+			return nil, err
+		}
+		return literalInput{lit}, nil
+	})
+}
+
+// decodeLiteralInput decodes the input with the decoder of the argument's type
+// if it was read back by SelectionsFromJSON.
+func decodeLiteralInput(spec InputSpec, input Input) (Input, error) {
+	raw, ok := input.(literalInput)
+	if !ok {
+		return input, nil
+	}
+	return spec.Type.Decoder().DecodeInput(raw.lit.ToInput())
+}
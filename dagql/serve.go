@@ -0,0 +1,198 @@
+package dagql
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/dagger/dagger/engine/slog"
+)
+
+// ServeOption configures the handler set up by Server.Handler and
+// Server.ListenAndServe.
+type ServeOption func(*serveOpts)
+
+type serveOpts struct {
+	handler     http.Handler
+	corsOrigins []string
+	logger      *slog.Logger
+}
+
+// ServeHandler sets the handler serving GraphQL requests, in place of
+// NewDefaultHandler for the server.
+func ServeHandler(handler http.Handler) ServeOption {
+	return func(opts *serveOpts) {
+		opts.handler = handler
+	}
+}
+
+// ServeCORS allows cross-origin requests from the given origins, which are
+// otherwise denied. An origin of "*" allows any origin.
+func ServeCORS(origins ...string) ServeOption {
+	return func(opts *serveOpts) {
+		opts.corsOrigins = origins
+	}
+}
+
+// ServeLogger sets the logger that requests are logged to, in place of the
+// default logger. A nil logger disables request logging.
+func ServeLogger(logger *slog.Logger) ServeOption {
+	return func(opts *serveOpts) {
+		opts.logger = logger
+	}
+}
+
+// Handler returns an HTTP handler serving the server's API, with requests
+// logged to the default logger and panics recovered as PANIC errors, which can
+// be overridden with the given options. Cross-origin requests are only allowed
+// from the origins set with ServeCORS. Requests are
+// checked against the server's schema version, as in ServeHTTP.
+//
+// Introspection is served only if it's installed on the server, e.g. with
+// introspection.Install.
+func (s *Server) Handler(opts ...ServeOption) http.Handler {
+	o := serveOpts{
+		logger: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	handler := o.handler
+	if handler == nil {
+		handler = NewDefaultHandler(s)
+	}
+	handler = recoverHandler(s.shutdownHandler(s.schemaVersionHandler(handler)), o.logger)
+	if len(o.corsOrigins) > 0 {
+		handler = corsHandler(handler, o.corsOrigins)
+	}
+	if o.logger != nil {
+		handler = logHandler(handler, o.logger)
+	}
+	return handler
+}
+
+// ListenAndServe serves the server's API over HTTP on the given address, as
+// set up by Handler.
+func (s *Server) ListenAndServe(addr string, opts ...ServeOption) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(opts...),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	return srv.ListenAndServe()
+}
+
+// corsAllowedHeaders are the request headers that cross-origin requests may
+// set, i.e. those read by the server.
+var corsAllowedHeaders = strings.Join([]string{
+	"Accept",
+	"Authorization",
+	"Content-Type",
+	"X-Request-Id",
+	MinSchemaVersionHeader,
+	QueryTimeoutHeader,
+}, ", ")
+
+// corsHandler allows cross-origin requests from the given origins, answering
+// preflight requests itself.
+func corsHandler(next http.Handler, origins []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		for _, allowed := range origins {
+			if allowed != "*" && allowed != origin {
+				continue
+			}
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			if allowed != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			break
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverHandler responds with a PANIC error if the handler panics, logging
+// the panic to the given logger, if any.
+func recoverHandler(next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			if logger != nil {
+				logger.Error("panic while serving request", "path", r.URL.Path, "panic", recovered, "stack", string(debug.Stack()))
+			}
+			writeGraphQLResponse(w, http.StatusInternalServerError, &graphql.Response{
+				Errors: gqlErrs(Errorf(ErrCodePanic, "panic while serving request: %v", recovered)),
+			})
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logHandler logs each request once it's served.
+func logHandler(next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		logger.Info("served request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start))
+	})
+}
+
+// statusRecorder records the status of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Flush flushes incremental responses, e.g. of queries using @defer.
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hands over the connection, e.g. to serve websockets.
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer cannot be hijacked")
+	}
+	rec.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -5,36 +5,49 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
-	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
-	"github.com/99designs/gqlgen/graphql/errcode"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/iancoleman/strcase"
 	"github.com/opencontainers/go-digest"
 	"github.com/sourcegraph/conc/pool"
 	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
 	"github.com/vektah/gqlparser/v2/gqlerror"
-	"github.com/vektah/gqlparser/v2/parser"
-	"github.com/vektah/gqlparser/v2/validator"
 	"github.com/vektah/gqlparser/v2/validator/rules"
 	"github.com/zeebo/xxh3"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/engine/slog"
 )
 
 // Server represents a GraphQL server whose schema is dynamically modified at
 // runtime.
 type Server struct {
-	root       AnyObjectResult
+	// root is shared by copies of the server, so that they all see the root
+	// replaced by SetRootType.
+	root       *atomic.Pointer[AnyObjectResult]
+	shutdown   *shutdownState
 	telemetry  AroundFunc
 	objects    map[string]ObjectType
 	scalars    map[string]ScalarType
 	typeDefs   map[string]TypeDef
 	directives map[string]DirectiveSpec
+	interfaces map[string]Typed
+	implements map[string][]string
+	unions     map[string]union
 
 	schemas       map[call.View]*ast.Schema
 	schemaDigests map[call.View]digest.Digest
@@ -44,6 +57,28 @@ type Server struct {
 	installLock  *sync.Mutex
 	installHooks []InstallHook
 
+	// handlers is shared by copies of the server, like the types installed on
+	// it.
+	handlers *atomic.Pointer[handlerSet]
+
+	// experimentalWarned holds the experimental fields that have already been
+	// warned about, so each is only logged once.
+	experimentalWarned *sync.Map
+
+	schemaVersion string
+
+	tracer             trace.Tracer
+	metrics            *serverMetrics
+	persistedQueries   PersistedQueryStore
+	parallelOperations bool
+	strictMode         bool
+	queryTimeout       time.Duration
+
+	maxComplexity      int
+	maxDepth           int
+	maxConcurrency     int
+	maxRequestBodySize int64
+
 	// View is the default view that is applied to queries on this server.
 	//
 	// WARNING: this is *not* the view of the current query (for that, inspect
@@ -106,16 +141,25 @@ type TypeDef interface {
 // NewServer returns a new Server with the given root object.
 func NewServer[T Typed](root T, c *SessionCache) *Server {
 	srv := &Server{
-		Cache:         c,
-		objects:       map[string]ObjectType{},
-		scalars:       map[string]ScalarType{},
-		typeDefs:      map[string]TypeDef{},
-		directives:    map[string]DirectiveSpec{},
-		installLock:   &sync.Mutex{},
-		schemas:       make(map[call.View]*ast.Schema),
-		schemaDigests: make(map[call.View]digest.Digest),
-		schemaOnces:   make(map[call.View]*sync.Once),
-		schemaLock:    &sync.Mutex{},
+		Cache:              c,
+		objects:            map[string]ObjectType{},
+		scalars:            map[string]ScalarType{},
+		typeDefs:           map[string]TypeDef{},
+		directives:         map[string]DirectiveSpec{},
+		interfaces:         map[string]Typed{},
+		implements:         map[string][]string{},
+		unions:             map[string]union{},
+		installLock:        &sync.Mutex{},
+		handlers:           &atomic.Pointer[handlerSet]{},
+		experimentalWarned: &sync.Map{},
+		root:               &atomic.Pointer[AnyObjectResult]{},
+		shutdown:           &shutdownState{},
+		schemas:            make(map[call.View]*ast.Schema),
+		schemaDigests:      make(map[call.View]digest.Digest),
+		schemaOnces:        make(map[call.View]*sync.Once),
+		schemaLock:         &sync.Mutex{},
+
+		maxRequestBodySize: DefaultMaxRequestBodySize,
 	}
 	rootClass := NewClass(srv, ClassOpts[T]{
 		// NB: there's nothing actually stopping this from being a thing, except it
@@ -123,10 +167,12 @@ func NewServer[T Typed](root T, c *SessionCache) *Server {
 		// around global config I suppose.
 		NoIDs: true,
 	})
-	srv.root = ObjectResult[T]{
+	var rootRes AnyObjectResult = ObjectResult[T]{
 		Result: Result[T]{self: root},
 		class:  rootClass,
 	}
+	srv.root.Store(&rootRes)
+	srv.handlers.Store(newHandlerSet())
 	srv.InstallObject(rootClass)
 	for _, scalar := range coreScalars {
 		srv.InstallScalar(scalar)
@@ -137,6 +183,30 @@ func NewServer[T Typed](root T, c *SessionCache) *Server {
 	return srv
 }
 
+// SetRootType replaces the root object of the server, keeping the types and
+// fields installed on it, e.g. to reload the root's configuration. The new
+// root must have the same type as the current one.
+//
+// Queries that are already executing keep the previous root, and results
+// already cached are not invalidated.
+func SetRootType[T Typed](srv *Server, root T) error {
+	srv.installLock.Lock()
+	defer srv.installLock.Unlock()
+	if name, curName := root.Type().Name(), srv.Root().Type().Name(); name != curName {
+		return fmt.Errorf("cannot replace root of type %s with %s", curName, name)
+	}
+	class, ok := srv.Root().ObjectType().(Class[T])
+	if !ok {
+		return fmt.Errorf("cannot replace root of type %s with %T", srv.Root().Type().Name(), root)
+	}
+	var rootRes AnyObjectResult = ObjectResult[T]{
+		Result: Result[T]{self: root},
+		class:  class,
+	}
+	srv.root.Store(&rootRes)
+	return nil
+}
+
 func (s *Server) invalidateSchemaCache() {
 	s.schemaLock.Lock()
 	clear(s.schemas)
@@ -146,8 +216,31 @@ func (s *Server) invalidateSchemaCache() {
 }
 
 func NewDefaultHandler(es graphql.ExecutableSchema) *handler.Server {
-	// TODO: avoid this deprecated method, and customize the options
-	srv := handler.NewDefaultServer(es)
+	srv := handler.New(es)
+
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	// must come before POST, which would otherwise serve the same requests
+	// without incremental delivery
+	srv.AddTransport(transport.MultipartMixed{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	srv.Use(extension.Introspection{})
+	srv.AroundOperations(requestMetadataOperation)
+
+	var apqCache graphql.Cache[string] = lru.New[string](100)
+	if dagSrv, ok := es.(*Server); ok && dagSrv.persistedQueries != nil {
+		apqCache = persistedQueryCache{dagSrv.persistedQueries}
+	}
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: apqCache,
+	})
 
 	srv.SetValidationRulesFn(func() *rules.Rules {
 		validationRules := rules.NewDefaultRules()
@@ -225,6 +318,46 @@ var coreDirectives = []DirectiveSpec{
 			DirectiveLocationEnumValue,
 		},
 	},
+	{
+		Name: "skip",
+		Description: FormatDescription(
+			`The @skip built-in directive may be provided for fields, fragment
+			spreads, and inline fragments, and allows for conditional exclusion
+			during execution as described by the if argument.`),
+		Args: NewInputSpecs(
+			InputSpec{
+				Name:        "if",
+				Description: FormatDescription(`Skipped when true.`),
+				Type:        Boolean(false),
+			},
+		),
+		Locations: []DirectiveLocation{
+			DirectiveLocationField,
+			DirectiveLocationFragmentSpread,
+			DirectiveLocationInlineFragment,
+		},
+	},
+	{
+		Name: "include",
+		Description: FormatDescription(
+			`The @include built-in directive may be provided for fields, fragment
+			spreads, and inline fragments, and allows for conditional inclusion
+			during execution as described by the if argument.`),
+		Args: NewInputSpecs(
+			InputSpec{
+				Name:        "if",
+				Description: FormatDescription(`Included when true.`),
+				Type:        Boolean(false),
+			},
+		),
+		Locations: []DirectiveLocation{
+			DirectiveLocationField,
+			DirectiveLocationFragmentSpread,
+			DirectiveLocationInlineFragment,
+		},
+	},
+	deferDirective,
+	streamDirective,
 	{
 		Name:        "sourceMap",
 		Description: FormatDescription(`Indicates the source information for where a given field is defined.`),
@@ -304,18 +437,76 @@ var coreDirectives = []DirectiveSpec{
 
 // Root returns the root object of the server. It is suitable for passing to
 // Resolve to resolve a query.
+//
+// The ID of the root object is nil, which the IDs of fields selected on it
+// use as their receiver to imply the root.
 func (s *Server) Root() AnyObjectResult {
-	return s.root
+	return *s.root.Load()
+}
+
+// RootFieldFunc resolves a field registered with RegisterRootField.
+type RootFieldFunc func(ctx context.Context, args map[string]Input) (Typed, error)
+
+// RegisterRootField adds a field to the root object of the server, without
+// needing its concrete type, e.g. for fields that are only known at runtime.
+//
+// The field's arguments are passed to fn as-is. It may return a Result to set
+// the field's ID, or any other Typed value to use the ID of the call.
+func (s *Server) RegisterRootField(spec FieldSpec, fn RootFieldFunc) {
+	s.Root().ObjectType().Extend(spec, func(ctx context.Context, _ AnyResult, args map[string]Input) (AnyResult, error) {
+		val, err := fn(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		if res, ok := val.(AnyResult); ok {
+			return res, nil
+		}
+		return NewResultForCurrentID(ctx, val)
+	}, CacheSpec{})
 }
 
 // InstallObject installs the given Object type into the schema, or returns the
 // previously installed type if it was already present
 func (s *Server) InstallObject(class ObjectType) ObjectType {
 	s.installLock.Lock()
+	installed, isNew := s.installObject(class)
+	s.installLock.Unlock()
+
+	if isNew {
+		for _, hook := range s.installHooks {
+			hook.InstallObject(installed)
+		}
+	}
+
+	return installed
+}
+
+// Install installs the given Object types into the schema like InstallObject,
+// holding the install lock for the whole batch so that the types are added
+// together rather than one at a time.
+func (s *Server) Install(types ...ObjectType) {
+	s.installLock.Lock()
+	var added []ObjectType
+	for _, class := range types {
+		if installed, isNew := s.installObject(class); isNew {
+			added = append(added, installed)
+		}
+	}
+	s.installLock.Unlock()
+
+	for _, class := range added {
+		for _, hook := range s.installHooks {
+			hook.InstallObject(class)
+		}
+	}
+}
 
+// installObject installs the given Object type unless one of the same name is
+// already present, returning the installed type and whether it's new. The
+// install lock must be held.
+func (s *Server) installObject(class ObjectType) (ObjectType, bool) {
 	if class, ok := s.objects[class.TypeName()]; ok {
-		s.installLock.Unlock()
-		return class
+		return class, false
 	}
 
 	s.invalidateSchemaCache()
@@ -356,13 +547,7 @@ func (s *Server) InstallObject(class ObjectType) ObjectType {
 			},
 		)
 	}
-	s.installLock.Unlock()
-
-	for _, hook := range s.installHooks {
-		hook.InstallObject(class)
-	}
-
-	return class
+	return class, true
 }
 
 // InstallScalar installs the given Scalar type into the schema, or returns the
@@ -418,6 +603,29 @@ func (s *Server) TypeDef(name string) (TypeDef, bool) {
 	return t, ok
 }
 
+// TypeNames returns the sorted names of all installed types, including
+// objects, scalars, type definitions, interfaces, and unions.
+func (s *Server) TypeNames() []string {
+	s.installLock.Lock()
+	defer s.installLock.Unlock()
+	names := slices.Concat(
+		slices.Collect(maps.Keys(s.objects)),
+		slices.Collect(maps.Keys(s.scalars)),
+		slices.Collect(maps.Keys(s.typeDefs)),
+		slices.Collect(maps.Keys(s.interfaces)),
+		slices.Collect(maps.Keys(s.unions)),
+	)
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// ScalarNames returns the sorted names of all installed scalar types.
+func (s *Server) ScalarNames() []string {
+	s.installLock.Lock()
+	defer s.installLock.Unlock()
+	return slices.Sorted(maps.Keys(s.scalars))
+}
+
 // Around installs a function to be called around every non-cached selection.
 func (s *Server) Around(rec AroundFunc) {
 	s.telemetry = rec
@@ -450,6 +658,7 @@ func (s *Server) Schema() *ast.Schema {
 		schema := &ast.Schema{
 			Types:         make(map[string]*ast.Definition),
 			PossibleTypes: make(map[string][]*ast.Definition),
+			Implements:    make(map[string][]*ast.Definition),
 		}
 		for _, t := range s.objects { // TODO stable order
 			def := definition(ast.Object, t, view)
@@ -469,6 +678,8 @@ func (s *Server) Schema() *ast.Schema {
 			schema.AddTypes(def)
 			schema.AddPossibleType(def.Name, def)
 		}
+		s.interfaceDefinitions(schema, view)
+		s.unionDefinitions(schema, view)
 		schema.Directives = map[string]*ast.DirectiveDefinition{}
 		for n, d := range s.directives {
 			schema.Directives[n] = d.DirectiveDefinition(view)
@@ -482,6 +693,17 @@ func (s *Server) Schema() *ast.Schema {
 	return s.schemas[view]
 }
 
+// FieldDefinition returns the schema definition of the named field of the
+// named object, input, or interface type, as seen from the server's View.
+func (s *Server) FieldDefinition(typeName, fieldName string) (*ast.FieldDefinition, bool) {
+	def, ok := s.Schema().Types[typeName]
+	if !ok {
+		return nil, false
+	}
+	field := def.Fields.ForName(fieldName)
+	return field, field != nil
+}
+
 // SchemaDigest returns the digest of the current schema.
 func (s *Server) SchemaDigest() digest.Digest {
 	s.Schema() // ensure it's built
@@ -490,9 +712,53 @@ func (s *Server) SchemaDigest() digest.Digest {
 	return s.schemaDigests[s.View]
 }
 
+// SDL returns the current schema of the server in the GraphQL Schema
+// Definition Language.
+//
+// Types and directives are sorted by name, so the output is stable regardless
+// of the order in which they were installed.
+func (s *Server) SDL() string {
+	schema := *s.Schema()
+	// the formatter expects every directive to have a source position, which
+	// ours don't since they aren't parsed from a document
+	schema.Directives = make(map[string]*ast.DirectiveDefinition, len(schema.Directives))
+	for name, def := range s.Schema().Directives {
+		if def.Position == nil {
+			withPos := *def
+			withPos.Position = &ast.Position{Src: &ast.Source{}}
+			def = &withPos
+		}
+		schema.Directives[name] = def
+	}
+	var buf strings.Builder
+	formatter.NewFormatter(&buf).FormatSchema(&schema)
+	return buf.String()
+}
+
 // Complexity returns the complexity of the given field.
+//
+// List fields multiply the complexity of their children by their `first` or
+// `limit` argument, if present; all other fields use the default complexity.
 func (s *Server) Complexity(ctx context.Context, typeName, field string, childComplexity int, args map[string]any) (int, bool) {
-	// TODO
+	class, ok := s.ObjectType(typeName)
+	if !ok {
+		return 1, false
+	}
+	spec, ok := class.FieldSpec(field, s.View)
+	if !ok || spec.Type.Type().Elem == nil {
+		return 1, false
+	}
+	for _, name := range listSizeArgs {
+		n, ok := args[name].(int64)
+		if !ok {
+			if i, isInt := args[name].(int); isInt {
+				n, ok = int64(i), true
+			}
+		}
+		if ok && n > 0 {
+			return int(n) * (childComplexity + 1), true
+		}
+	}
 	return 1, false
 }
 
@@ -504,29 +770,66 @@ type ExtendedError interface {
 
 // Exec implements graphql.ExecutableSchema.
 func (s *Server) Exec(ctx1 context.Context) graphql.ResponseHandler {
-	return func(ctx context.Context) (res *graphql.Response) {
+	// set once the initial response has been returned to a client that accepts
+	// incremental delivery, whose payloads are then returned by subsequent calls
+	var delivery *incrementalDelivery
+	var executed bool
+	// only the initial execution is wrapped, since the payloads that follow
+	// are part of the same request
+	initial := s.execHandler(func(ctx context.Context) *graphql.Response {
 		gqlOp := graphql.GetOperationContext(ctx)
+		if !acceptsIncrementalDelivery(gqlOp) {
+			return s.execResponse(ctx, gqlOp)
+		}
 
-		if err := gqlOp.Validate(ctx); err != nil {
-			return graphql.ErrorResponse(ctx, "validate: %s", err)
+		delivery = newIncrementalDelivery()
+		res := s.execResponse(incrementalDeliveryToContext(ctx, delivery), gqlOp)
+		hasNext := res.Data != nil && delivery.hasNext()
+		if !hasNext {
+			// nothing to deliver, or nothing to deliver it into
+			delivery.stop()
+		}
+		res.HasNext = &hasNext
+		return res
+	})
+	return func(ctx context.Context) *graphql.Response {
+		if !executed {
+			executed = true
+			return initial(ctx)
+		}
+		if delivery == nil {
+			return nil
 		}
+		return delivery.next(ctx)
+	}
+}
 
-		results, err := s.ExecOp(ctx, gqlOp)
-		if err != nil {
-			return &graphql.Response{
-				Errors: gqlErrs(err),
-			}
+// execResponse executes the operation and returns its response.
+func (s *Server) execResponse(ctx context.Context, gqlOp *graphql.OperationContext) *graphql.Response {
+	if err := gqlOp.Validate(ctx); err != nil {
+		return &graphql.Response{
+			Errors: gqlErrs(NewError(ErrCodeInvalidArgument, fmt.Errorf("validate: %w", err))),
 		}
+	}
 
-		data, err := json.Marshal(results)
-		if err != nil {
-			return graphql.ErrorResponse(ctx, "marshal: %s", err)
+	results, execErr := s.ExecOp(ctx, gqlOp)
+	if execErr != nil && results == nil {
+		return &graphql.Response{
+			Errors: gqlErrs(execErr),
 		}
+	}
 
+	data, err := json.Marshal(results)
+	if err != nil {
 		return &graphql.Response{
-			Data: json.RawMessage(data),
+			Errors: gqlErrs(NewError(ErrCodeInternal, fmt.Errorf("marshal: %w", err))),
 		}
 	}
+
+	return &graphql.Response{
+		Data:   json.RawMessage(data),
+		Errors: gqlErrs(execErr),
+	}
 }
 
 func gqlErrs(err error) (errs gqlerror.List) {
@@ -543,23 +846,54 @@ func gqlErrs(err error) (errs gqlerror.List) {
 	return
 }
 
+// ExecOp executes the operations of the given document.
+//
+// Nullable fields that fail resolve to null rather than failing the whole
+// request, in which case the partial results are returned along with the
+// errors.
 func (s *Server) ExecOp(ctx context.Context, gqlOp *graphql.OperationContext) (map[string]any, error) {
-	if gqlOp.Doc == nil {
-		var err error
-		gqlOp.Doc, err = parser.ParseQuery(&ast.Source{Input: gqlOp.RawQuery})
+	if !s.shutdown.begin() {
+		return nil, errShuttingDown
+	}
+	defer s.shutdown.end()
+	timeout, err := s.timeoutFor(gqlOp)
+	if err != nil {
+		return nil, err
+	}
+	if timeout == 0 {
+		return s.execOp(ctx, gqlOp)
+	}
+	return s.execOpWithTimeout(ctx, gqlOp, timeout)
+}
+
+// execOp executes the operations of the given document, collecting the
+// errors of nullable fields.
+func (s *Server) execOp(ctx context.Context, gqlOp *graphql.OperationContext) (map[string]any, error) {
+	fe := &fieldErrors{}
+	results, err := s.execOps(fieldErrorsToContext(ctx, fe), gqlOp)
+	if errs := fe.list(); len(errs) > 0 {
 		if err != nil {
-			return nil, gqlErrs(err)
+			return nil, append(errs, gqlErrs(err)...)
 		}
+		return results, errs
+	}
+	return results, err
+}
 
-		//nolint:staticcheck // annoying, but we can't easily switch to this without inconsistencies
-		listErr := validator.Validate(s.Schema(), gqlOp.Doc)
-		if len(listErr) != 0 {
-			for _, e := range listErr {
-				errcode.Set(e, errcode.ValidationFailed)
-			}
-			return nil, listErr
+func (s *Server) execOps(ctx context.Context, gqlOp *graphql.OperationContext) (map[string]any, error) {
+	if gqlOp.Doc == nil {
+		var err error
+		gqlOp.Doc, err = s.parseQuery(gqlOp.RawQuery)
+		if err != nil {
+			return nil, err
 		}
 	}
+	if s.maxConcurrency > 0 && concurrencyLimitFromContext(ctx) == nil {
+		ctx = concurrencyLimitToContext(ctx, make(concurrencyLimit, s.maxConcurrency))
+	}
+	if s.parallelOperations && gqlOp.OperationName == "" && len(gqlOp.Doc.Operations) > 1 {
+		return s.execOpsParallel(ctx, gqlOp)
+	}
 	results := make(map[string]any)
 	for _, op := range gqlOp.Doc.Operations {
 		switch op.Operation {
@@ -567,11 +901,8 @@ func (s *Server) ExecOp(ctx context.Context, gqlOp *graphql.OperationContext) (m
 			if gqlOp.OperationName != "" && gqlOp.OperationName != op.Name {
 				continue
 			}
-			sels, err := s.parseASTSelections(ctx, gqlOp, s.root.Type(), op.SelectionSet)
-			if err != nil {
-				return nil, fmt.Errorf("query:\n%s\n\nerror: parse selections: %w", gqlOp.RawQuery, err)
-			}
-			results, err = s.Resolve(ctx, s.root, sels...)
+			var err error
+			results, err = s.execQuery(ctx, gqlOp, op)
 			if err != nil {
 				return nil, err
 			}
@@ -586,20 +917,162 @@ func (s *Server) ExecOp(ctx context.Context, gqlOp *graphql.OperationContext) (m
 	return results, nil
 }
 
+// SetParallelOperations configures whether the operations of a document are
+// executed in parallel when no operation name is given, e.g. for batches of
+// queries sent as a single multi-operation document.
+//
+// The results of all operations are merged into a single response, with
+// later operations taking precedence for any conflicting keys.
+func (s *Server) SetParallelOperations(parallel bool) {
+	s.parallelOperations = parallel
+}
+
+// SetStrictMode configures whether selecting a deprecated field fails instead
+// of resolving it, e.g. to enforce the end of a deprecation period in CI.
+func (s *Server) SetStrictMode(strict bool) {
+	s.strictMode = strict
+}
+
+// checkDeprecated returns an error if the server is in strict mode and the
+// selected field is deprecated.
+func (s *Server) checkDeprecated(self AnyObjectResult, spec *FieldSpec) error {
+	if !s.strictMode || spec.DeprecatedReason == "" {
+		return nil
+	}
+	return NewError(ErrCodeInvalidArgument, fmt.Errorf("%s.%s is deprecated: %s",
+		self.Type().Name(), spec.Name, spec.DeprecatedReason))
+}
+
+// warnExperimental logs a warning the first time an experimental field is
+// selected.
+func (s *Server) warnExperimental(ctx context.Context, self AnyObjectResult, spec *FieldSpec) {
+	if spec.ExperimentalReason == "" {
+		return
+	}
+	field := self.Type().Name() + "." + spec.Name
+	if _, warned := s.experimentalWarned.LoadOrStore(field, struct{}{}); warned {
+		return
+	}
+	slog.WarnContext(ctx, "experimental field selected",
+		"field", field,
+		"reason", spec.ExperimentalReason)
+}
+
+// AuthFunc decides whether a field may be selected on the given object with
+// the given arguments, returning an error if not. The context is the request's,
+// so it carries any credentials set by the handler.
+type AuthFunc func(ctx context.Context, self AnyObjectResult, args map[string]Input) error
+
+// checkCall returns an error if the field may not be called on self with the
+// given arguments, because its AuthFunc denies it, the number of arguments is
+// out of bounds, or its rate limit is exceeded; it waits for the rate limit
+// otherwise.
+//
+// It runs for every call, so that loading an ID can't bypass it.
+func checkCall(ctx context.Context, self AnyObjectResult, spec *FieldSpec, args []NamedInput) error {
+	if spec.authorize != nil {
+		argVals := make(map[string]Input, len(args))
+		for _, arg := range args {
+			argVals[arg.Name] = arg.Value
+		}
+		if err := spec.authorize(ctx, self, argVals); err != nil {
+			return NewError(ErrCodePermissionDenied, fmt.Errorf("not authorized to select %s.%s: %w",
+				self.Type().Name(), spec.Name, err))
+		}
+	}
+	if err := checkArgCount(self.Type().Name(), spec, len(args)); err != nil {
+		return err
+	}
+	if spec.limiter != nil {
+		if err := spec.limiter.Wait(ctx); err != nil {
+			return NewError(ErrCodeResourceExhausted, fmt.Errorf("rate limit of %s.%s exceeded: %w",
+				self.Type().Name(), spec.Name, err))
+		}
+	}
+	return nil
+}
+
+// checkArgCount returns an error if n arguments are out of the bounds set by
+// the field's MinArgs and MaxArgs.
+func checkArgCount(typeName string, spec *FieldSpec, n int) error {
+	if spec.minArgs > 0 && n < spec.minArgs {
+		return Errorf(ErrCodeInvalidArgument, "%s.%s requires at least %d arguments, got %d", typeName, spec.Name, spec.minArgs, n)
+	}
+	if spec.maxArgs > 0 && n > spec.maxArgs {
+		return Errorf(ErrCodeInvalidArgument, "%s.%s accepts at most %d arguments, got %d", typeName, spec.Name, spec.maxArgs, n)
+	}
+	return nil
+}
+
+// execOpsParallel executes all operations of the document in parallel and
+// merges their results in document order.
+func (s *Server) execOpsParallel(ctx context.Context, gqlOp *graphql.OperationContext) (map[string]any, error) {
+	for _, op := range gqlOp.Doc.Operations {
+		switch op.Operation {
+		case ast.Mutation:
+			// TODO
+			return nil, fmt.Errorf("mutations not supported")
+		case ast.Subscription:
+			// TODO
+			return nil, fmt.Errorf("subscriptions not supported")
+		}
+	}
+
+	opResults := make([]map[string]any, len(gqlOp.Doc.Operations))
+	pool := pool.New().WithErrors()
+	for i, op := range gqlOp.Doc.Operations {
+		pool.Go(func() error {
+			res, err := s.execQuery(ctx, gqlOp, op)
+			if err != nil {
+				return err
+			}
+			opResults[i] = res
+			return nil
+		})
+	}
+	if err := pool.Wait(); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]any)
+	for _, res := range opResults {
+		maps.Copy(results, res)
+	}
+	return results, nil
+}
+
+// execQuery executes a single query operation.
+func (s *Server) execQuery(ctx context.Context, gqlOp *graphql.OperationContext, op *ast.OperationDefinition) (map[string]any, error) {
+	// arguments are decoded with the decoders registered on this server
+	sels, err := s.parseASTSelections(srvToContext(ctx, s), gqlOp, s.Root().Type(), op.SelectionSet)
+	if err != nil {
+		return nil, fmt.Errorf("query:\n%s\n\nerror: parse selections: %w", gqlOp.RawQuery, err)
+	}
+	if err := s.checkLimits(sels); err != nil {
+		return nil, err
+	}
+	return s.Resolve(ctx, s.Root(), sels...)
+}
+
 // Resolve resolves the given selections on the given object.
 //
 // Each selection is resolved in parallel, and the results are returned in a
 // map whose keys correspond to the selection's field name or alias.
 func (s *Server) Resolve(ctx context.Context, self AnyObjectResult, sels ...Selection) (map[string]any, error) {
+	sels = applicableSelections(self, sels)
+	if d := incrementalDeliveryFromContext(ctx); d != nil {
+		sels = s.deferSelections(ctx, d, self, sels)
+	}
 	if len(sels) == 0 {
-		return nil, nil
+		// e.g. all fields were skipped, or none apply to the concrete type
+		return map[string]any{}, nil
 	}
 
 	if len(sels) == 1 {
 		sel := sels[0]
 		// Resolve is in the hot path, so avoiding overhead of goroutines, sync.Map, etc. when there's only
 		// one selection (probably the most common case) likely pays off.
-		res, err := s.resolvePath(ctx, self, sel)
+		res, err := s.resolveNullable(ctx, self, sel)
 		if err != nil {
 			return nil, gqlErrs(err)
 		}
@@ -608,18 +1081,34 @@ func (s *Server) Resolve(ctx context.Context, self AnyObjectResult, sels ...Sele
 
 	results := new(sync.Map)
 
+	limit := concurrencyLimitFromContext(ctx)
+	var inlineErrs []error
 	pool := pool.New().WithErrors()
 	for _, sel := range sels {
-		pool.Go(func() error {
-			res, err := s.resolvePath(ctx, self, sel)
+		resolve := func() error {
+			res, err := s.catchPanic(ctx, self, sel, func() (any, error) {
+				return s.resolveNullable(ctx, self, sel)
+			})
 			if err != nil {
 				return err
 			}
 			results.Store(sel.Name(), res)
 			return nil
+		}
+		if !limit.tryAcquire() {
+			// resolve in this goroutine rather than waiting for capacity, which
+			// would deadlock if it's held by our parents
+			if err := resolve(); err != nil {
+				inlineErrs = append(inlineErrs, err)
+			}
+			continue
+		}
+		pool.Go(func() error {
+			defer limit.release()
+			return resolve()
 		})
 	}
-	if err := pool.Wait(); err != nil {
+	if err := errors.Join(append(inlineErrs, pool.Wait())...); err != nil {
 		return nil, gqlErrs(err)
 	}
 
@@ -637,7 +1126,98 @@ func (s *Server) Load(ctx context.Context, id *call.ID) (AnyObjectResult, error)
 	if err != nil {
 		return nil, fmt.Errorf("load: %w", err)
 	}
-	return s.toSelectable(res)
+	return s.toSelectable(ctx, res)
+}
+
+// BatchLoad loads the objects of the given IDs, returning them in the same
+// order.
+//
+// IDs are grouped by the first call in their chain, e.g. the same constructor
+// on the root object. The first call of each group is resolved only once, and
+// the rest of the IDs in the group are then resolved in parallel on top of it,
+// rather than each ID being resolved from scratch.
+func (s *Server) BatchLoad(ctx context.Context, ids []*call.ID) ([]AnyObjectResult, error) {
+	var groupKeys []digest.Digest
+	groups := map[digest.Digest][]int{}
+	firsts := map[digest.Digest]*call.ID{}
+	for i, id := range ids {
+		first := firstCall(id)
+		key := first.Digest()
+		if _, ok := groups[key]; !ok {
+			groupKeys = append(groupKeys, key)
+			firsts[key] = first
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	out := make([]AnyObjectResult, len(ids))
+	eg := new(errgroup.Group)
+	for _, key := range groupKeys {
+		eg.Go(func() error {
+			if _, err := s.LoadType(ctx, firsts[key]); err != nil {
+				return fmt.Errorf("load %s: %w", firsts[key].DisplayString(maxIDDisplayLen), err)
+			}
+			members := new(errgroup.Group)
+			for _, i := range groups[key] {
+				members.Go(func() error {
+					res, err := s.Load(ctx, ids[i])
+					if err != nil {
+						return err
+					}
+					out[i] = res
+					return nil
+				})
+			}
+			return members.Wait()
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// firstCall returns the first call in the chain of the given ID, i.e. the one
+// selected on the root object.
+func firstCall(id *call.ID) *call.ID {
+	for id.Receiver() != nil {
+		id = id.Receiver()
+	}
+	return id
+}
+
+// LoadByDigest loads the object cached for the given digest, without
+// evaluating the calls that produced it.
+//
+// Only the PostCall of the cached result itself is run, not those of the calls
+// it was derived from, so resources that those move between clients (e.g.
+// secrets) are not transferred. Use Load to load an ID from another client.
+func (s *Server) LoadByDigest(ctx context.Context, dgst digest.Digest) (AnyObjectResult, error) {
+	res, ok, err := s.loadCached(ctx, dgst)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", dgst, err)
+	}
+	if !ok {
+		return nil, Errorf(ErrCodeNotFound, "load %s: not found in cache", dgst)
+	}
+	return s.toSelectable(ctx, res)
+}
+
+// loadCached returns the result cached for the given digest, if any.
+func (s *Server) loadCached(ctx context.Context, dgst digest.Digest) (AnyResult, bool, error) {
+	res, ok := s.Cache.Get(ctx, string(dgst))
+	if !ok {
+		return nil, false, nil
+	}
+	if err := res.PostCall(ctx); err != nil {
+		return nil, false, fmt.Errorf("post-call error: %w", err)
+	}
+	val := res.Result()
+	if val == nil {
+		// a cached null isn't an object to load
+		return nil, false, nil
+	}
+	return val, true, nil
 }
 
 func (s *Server) LoadType(ctx context.Context, id *call.ID) (AnyResult, error) {
@@ -674,10 +1254,10 @@ func (s *Server) LoadType(ctx context.Context, id *call.ID) (AnyResult, error) {
 			return res, nil
 		}
 	} else {
-		base = s.root
+		base = s.Root()
 	}
 
-	baseObj, err := s.toSelectable(base)
+	baseObj, err := s.toSelectable(ctx, base)
 	if err != nil {
 		return nil, fmt.Errorf("toSelectable: %w", err)
 	}
@@ -754,7 +1334,7 @@ func (s *Server) Select(ctx context.Context, self AnyObjectResult, dest any, sel
 					continue
 				}
 				if isObj {
-					val, err = s.toSelectable(val)
+					val, err = s.toSelectable(ctx, val)
 					if err != nil {
 						return fmt.Errorf("select %dth array element: %w", nth, err)
 					}
@@ -767,7 +1347,7 @@ func (s *Server) Select(ctx context.Context, self AnyObjectResult, dest any, sel
 		} else if s.isObjectType(res.Type().Name()) {
 			// if the result is an Object, set it as the next selection target, and
 			// assign res to the "hydrated" Object
-			self, err = s.toSelectable(res)
+			self, err = s.toSelectable(ctx, res)
 			if err != nil {
 				return err
 			}
@@ -813,6 +1393,16 @@ func LoadIDs[T Typed](ctx context.Context, srv *Server, ids []ID[T]) ([]T, error
 	return out, nil
 }
 
+// Must returns the value if err is nil, and panics with err otherwise, e.g.
+// `dagql.Must(srv.Load(ctx, id))`. It's meant for tests and examples, where
+// failing early is simpler than checking each error.
+func Must[T Typed](val T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return val
+}
+
 func LoadIDResults[T Typed](ctx context.Context, srv *Server, ids []ID[T]) ([]ObjectResult[T], error) {
 	out := make([]ObjectResult[T], len(ids))
 	eg := new(errgroup.Group)
@@ -949,6 +1539,10 @@ func gqlErr(rerr error, path ast.Path) *gqlerror.Error {
 		}
 		return gqlErr
 	}
+	var dagErr *Error
+	if errors.As(rerr, &dagErr) && len(dagErr.Path) > 0 {
+		path = dagErr.astPath()
+	}
 	gqlErr = &gqlerror.Error{
 		Err:     rerr,
 		Message: rerr.Error(),
@@ -962,14 +1556,22 @@ func gqlErr(rerr error, path ast.Path) *gqlerror.Error {
 }
 
 func (s *Server) resolvePath(ctx context.Context, self AnyObjectResult, sel Selection) (res any, rerr error) {
+	// looked up once for everything below that depends on the field's spec
+	spec, hasSpec := self.ObjectType().FieldSpec(sel.Selector.Field, sel.Selector.View)
+
+	if s.tracer != nil {
+		var span trace.Span
+		ctx, span = s.tracer.Start(ctx, self.Type().Name()+"."+sel.Selector.Field,
+			trace.WithAttributes(selectionSpanAttrs(spec, sel.Selector)...))
+		// deferred first so that it sees errors recovered from panics below
+		defer func() { endSelectionSpan(span, rerr) }()
+	}
+
+	ctx = appendResponsePath(ctx, ast.PathName(sel.Name()))
+
 	defer func() {
 		if r := recover(); r != nil {
-			rerr = PanicError{
-				Cause:     r,
-				Self:      self,
-				Selection: sel,
-				Stack:     debug.Stack(),
-			}
+			rerr = s.panicError(ctx, r, self, sel)
 		}
 
 		if rerr != nil {
@@ -984,7 +1586,32 @@ func (s *Server) resolvePath(ctx context.Context, self AnyObjectResult, sel Sele
 		return nil, fmt.Errorf("cannot resolve selector path with nth")
 	}
 
-	val, err := self.Select(ctx, s, sel.Selector)
+	if sel.Selector.Field == typenameField {
+		return self.Type().Name(), nil
+	}
+	if !hasSpec {
+		return nil, fmt.Errorf("%s has no such field: %q", self.Type().Name(), sel.Selector.Field)
+	}
+
+	if err := s.checkDeprecated(self, &spec); err != nil {
+		return nil, err
+	}
+	s.warnExperimental(ctx, self, &spec)
+
+	s.emit(ctx, OnResolveStart, HookData{
+		Self:     self,
+		Selector: sel.Selector,
+	})
+	start := time.Now()
+	val, err := s.selectFieldWithTimeout(ctx, self, &spec, sel)
+	s.metrics.observeField(self.Type().Name(), sel.Selector.Field, start)
+	s.emit(ctx, OnResolveEnd, HookData{
+		Self:     self,
+		Selector: sel.Selector,
+		Result:   val,
+		Err:      err,
+		Duration: time.Since(start),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -994,54 +1621,95 @@ func (s *Server) resolvePath(ctx context.Context, self AnyObjectResult, sel Sele
 		return nil, nil
 	}
 
-	enum, ok := val.Unwrap().(Enumerable)
-	if ok {
+	if _, ok := val.Unwrap().(Enumerable); ok {
 		// we're sub-selecting into an enumerable value, so we need to resolve each
 		// element
-
-		// TODO arrays of arrays
-		results := []any{} // TODO subtle: favor [] over null result
-		for nth := 1; nth <= enum.Len(); nth++ {
-			val, err := val.NthValue(nth)
-			if err != nil {
-				return nil, err
-			}
-			val, ok := val.DerefValue()
-			if !ok {
-				results = append(results, nil)
-				continue
-			}
-			if len(sel.Subselections) == 0 {
-				results = append(results, val.Unwrap())
-			} else {
-				node, err := s.toSelectable(val)
-				if err != nil {
-					return nil, fmt.Errorf("instantiate %dth array element: %w", nth, err)
-				}
-				res, err := s.Resolve(ctx, node, sel.Subselections...)
-				if err != nil {
-					return nil, err
-				}
-				results = append(results, res)
-			}
-		}
-		return results, nil
+		return s.resolveEnumerable(ctx, val, sel)
 	}
 
-	if len(sel.Subselections) == 0 {
-		return val.Unwrap(), nil
+	if sel.Subselections == nil {
+		return s.encodeLeaf(val.Unwrap())
 	}
 
 	// instantiate the return value so we can sub-select
-	node, err := s.toSelectable(val)
+	node, err := s.toSelectable(ctx, val)
 	if err != nil {
-		return nil, fmt.Errorf("instantiate: %w", err)
+		return nil, fmt.Errorf("instantiate %s: %w", val.ID().DisplayString(maxIDDisplayLen), err)
+	}
+
+	return s.Resolve(ctx, node, sel.Subselections...)
+}
+
+// resolveEnumerable resolves the selection on each element of an enumerable
+// value, recursing into elements that are themselves enumerable.
+//
+// If the selection is streamed and the client accepts incremental delivery,
+// only the elements up to its initial count are resolved, and the rest are
+// delivered later.
+func (s *Server) resolveEnumerable(ctx context.Context, val AnyResult, sel Selection) ([]any, error) {
+	enum := val.Unwrap().(Enumerable)
+	count := enum.Len()
+	if sel.Stream != nil && sel.Stream.InitialCount < count {
+		if d := incrementalDeliveryFromContext(ctx); d != nil && s.streamElements(ctx, d, val, sel, sel.Stream.InitialCount) {
+			count = sel.Stream.InitialCount
+		}
+	}
+	results := []any{} // TODO subtle: favor [] over null result
+	for nth := 1; nth <= count; nth++ {
+		res, err := s.resolveNth(appendResponsePath(ctx, ast.PathIndex(nth-1)), val, sel, nth)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
 	}
+	return results, nil
+}
 
+// resolveNth resolves the selection on the nth element of an enumerable value.
+func (s *Server) resolveNth(ctx context.Context, val AnyResult, sel Selection, nth int) (any, error) {
+	val, err := val.NthValue(nth)
+	if err != nil {
+		return nil, err
+	}
+	val, ok := val.DerefValue()
+	if !ok {
+		return nil, nil
+	}
+	if _, ok := val.Unwrap().(Enumerable); ok {
+		// only the outermost list is streamed
+		sel.Stream = nil
+		res, err := s.resolveEnumerable(ctx, val, sel)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %dth array element: %w", nth, err)
+		}
+		return res, nil
+	}
+	if sel.Subselections == nil {
+		return s.encodeLeaf(val.Unwrap())
+	}
+	node, err := s.toSelectable(ctx, val)
+	if err != nil {
+		return nil, fmt.Errorf("instantiate %s: %w", val.ID().DisplayString(maxIDDisplayLen), err)
+	}
 	return s.Resolve(ctx, node, sel.Subselections...)
 }
 
-func (s *Server) toSelectable(val AnyResult) (AnyObjectResult, error) {
+// toSelectable instantiates the value as an object to select fields on,
+// calling the OnCreate hooks of its type.
+func (s *Server) toSelectable(ctx context.Context, val AnyResult) (AnyObjectResult, error) {
+	obj, err := s.instantiate(val)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.runObjectHooks(ctx, obj, func(hook ObjectHook) ObjectHookFunc {
+		return hook.OnCreate
+	}); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (s *Server) instantiate(val AnyResult) (AnyObjectResult, error) {
 	if sel, ok := val.(AnyObjectResult); ok {
 		// We always support returning something that's already Selectable, e.g. an
 		// object loaded from its ID.
@@ -1078,15 +1746,46 @@ func (s *Server) toSelectable(val AnyResult) (AnyObjectResult, error) {
 func (s *Server) parseASTSelections(ctx context.Context, gqlOp *graphql.OperationContext, self *ast.Type, astSels ast.SelectionSet) ([]Selection, error) {
 	vars := gqlOp.Variables
 
-	class := s.objects[self.Name()]
-	if class == nil {
+	if s.isAbstractType(self.Name()) {
+		// the concrete type is only known once the field is resolved, so parse the
+		// selections against each possible type and pick the right ones at runtime
+		sels := []Selection{}
+		for _, typeName := range s.possibleTypes(self.Name()) {
+			typeSels, err := s.parseASTSelections(ctx, gqlOp, &ast.Type{NamedType: typeName}, astSels)
+			if err != nil {
+				return nil, err
+			}
+			for _, sel := range typeSels {
+				sel.TypeCondition = typeName
+				sels = append(sels, sel)
+			}
+		}
+		return sels, nil
+	}
+
+	class, ok := s.ObjectType(self.Name())
+	if !ok {
 		return nil, fmt.Errorf("parseASTSelections: not an Object type: %q", self.Name())
 	}
 
 	sels := []Selection{}
 	for _, sel := range astSels {
+		include, err := includeSelection(sel, vars)
+		if err != nil {
+			return nil, err
+		}
+		if !include {
+			continue
+		}
 		switch x := sel.(type) {
 		case *ast.Field:
+			if x.Name == typenameField {
+				sels = append(sels, Selection{
+					Alias:    x.Alias,
+					Selector: Selector{Field: typenameField},
+				})
+				continue
+			}
 			sel, resType, err := class.ParseField(ctx, s.View, x, vars)
 			if err != nil {
 				return nil, fmt.Errorf("parse field %q: %w", x.Name, err)
@@ -1099,23 +1798,52 @@ func (s *Server) parseASTSelections(ctx context.Context, gqlOp *graphql.Operatio
 				}
 			}
 
+			stream, err := parseStream(x, resType, vars)
+			if err != nil {
+				return nil, err
+			}
+			directives, err := s.handledDirectives(x.Directives, vars)
+			if err != nil {
+				return nil, err
+			}
+
 			sels = append(sels, Selection{
 				Alias:         x.Alias,
 				Selector:      sel,
 				Subselections: subsels,
+				Stream:        stream,
+				Directives:    directives,
 			})
 		case *ast.FragmentSpread:
 			fragment := gqlOp.Doc.Fragments.ForName(x.Name)
 			if fragment == nil {
 				return nil, fmt.Errorf("unknown fragment: %s", x.Name)
 			}
+			if !s.satisfiesType(self.Name(), fragment.TypeCondition) {
+				continue
+			}
 			if len(fragment.SelectionSet) > 0 {
 				subsels, err := s.parseASTSelections(ctx, gqlOp, self, fragment.SelectionSet)
 				if err != nil {
 					return nil, err
 				}
+				if err := deferFragment(subsels, x.Directives, vars); err != nil {
+					return nil, err
+				}
 				sels = append(sels, subsels...)
 			}
+		case *ast.InlineFragment:
+			if !s.satisfiesType(self.Name(), x.TypeCondition) {
+				continue
+			}
+			subsels, err := s.parseASTSelections(ctx, gqlOp, self, x.SelectionSet)
+			if err != nil {
+				return nil, err
+			}
+			if err := deferFragment(subsels, x.Directives, vars); err != nil {
+				return nil, err
+			}
+			sels = append(sels, subsels...)
 		default:
 			return nil, fmt.Errorf("unknown field type: %T", x)
 		}
@@ -1124,11 +1852,68 @@ func (s *Server) parseASTSelections(ctx context.Context, gqlOp *graphql.Operatio
 	return sels, nil
 }
 
+// includeSelection evaluates the @skip and @include directives of a selection,
+// returning false if it should be left out.
+func includeSelection(sel ast.Selection, vars map[string]any) (bool, error) {
+	var directives ast.DirectiveList
+	switch x := sel.(type) {
+	case *ast.Field:
+		directives = x.Directives
+	case *ast.FragmentSpread:
+		directives = x.Directives
+	case *ast.InlineFragment:
+		directives = x.Directives
+	}
+	for name, want := range map[string]bool{"skip": false, "include": true} {
+		directive := directives.ForName(name)
+		if directive == nil {
+			continue
+		}
+		arg := directive.Arguments.ForName("if")
+		if arg == nil {
+			return false, fmt.Errorf("@%s: missing argument \"if\"", name)
+		}
+		val, err := arg.Value.Value(vars)
+		if err != nil {
+			return false, fmt.Errorf("@%s: %w", name, err)
+		}
+		cond, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("@%s: expected Boolean, got %T", name, val)
+		}
+		if cond != want {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // Selection represents a selection of a field on an object.
 type Selection struct {
-	Alias         string
-	Selector      Selector
+	Alias    string
+	Selector Selector
+	// Subselections are the selections on the result of the field, which are
+	// nil for leaf fields. They may be empty, e.g. if all of them were skipped
+	// with @skip or @include.
 	Subselections []Selection
+
+	// TypeCondition restricts the selection to objects of the named type, if
+	// set. It is used for selections on abstract types, whose concrete type is
+	// only known at runtime.
+	TypeCondition string
+
+	// Stream configures the incremental delivery of the elements of a list
+	// field, if set.
+	Stream *Stream
+
+	// Defer configures the incremental delivery of the selection, if it's part
+	// of a deferred fragment. Selections of the same fragment share the same
+	// Defer.
+	Defer *Defer
+
+	// Directives are the directives applied to the field in the query that
+	// have handlers registered on the server.
+	Directives []AppliedDirective
 }
 
 // Name returns the name of the selection, which is either the alias or the
@@ -1166,6 +1951,11 @@ func (sel Selector) String() string {
 	return str
 }
 
+// Arg returns the value of the named argument, if it is set.
+func (sel Selector) Arg(name string) (Input, bool) {
+	return Inputs(sel.Args).Lookup(name)
+}
+
 type Inputs []NamedInput
 
 func (args Inputs) Lookup(name string) (Input, bool) {
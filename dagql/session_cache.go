@@ -3,6 +3,7 @@ package dagql
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 
 	"github.com/dagger/dagger/engine/cache"
@@ -19,7 +20,10 @@ type SessionCache struct {
 	cache cache.Cache[CacheKeyType, CacheValueType]
 
 	results []cache.Result[CacheKeyType, CacheValueType]
-	mu      sync.Mutex
+	// keys maps the result keys of results to their values, so that
+	// invalidation and RangeResults can be restricted to this session.
+	keys map[CacheKeyType]CacheValueType
+	mu   sync.Mutex
 
 	// isClosed is set to true when ReleaseAndClose is called.
 	// Any in-progress results will be released and errors returned.
@@ -33,6 +37,7 @@ func NewSessionCache(
 ) *SessionCache {
 	return &SessionCache{
 		cache: baseCache,
+		keys:  map[CacheKeyType]CacheValueType{},
 	}
 }
 
@@ -176,11 +181,101 @@ func (c *SessionCache) GetOrInitializeWithCallbacks(
 
 	if !isZero {
 		c.results = append(c.results, res)
+		c.keys[key.ResultKey] = res.Result()
 	}
 
 	return res, nil
 }
 
+// Get returns the completed result cached for the given key, if any. Like other
+// results of the session, it is released when the session cache is closed.
+func (c *SessionCache) Get(ctx context.Context, key CacheKeyType) (CacheResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isClosed {
+		return nil, false
+	}
+	res, ok := c.cache.Get(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	c.results = append(c.results, res)
+	c.keys[key] = res.Result()
+	return res, true
+}
+
+// Has reports whether a completed result is cached for the given key in the
+// underlying cache.
+func (c *SessionCache) Has(key CacheKeyType) bool {
+	return c.cache.Has(key)
+}
+
+// Range calls fn with the key and value of each completed result in the
+// underlying cache, including those of other sessions.
+func (c *SessionCache) Range(fn func(CacheKeyType, CacheValueType) bool) {
+	c.cache.Range(fn)
+}
+
+// RangeResults calls fn with the key and value of each result obtained through
+// this session that is still cached, until fn returns false.
+func (c *SessionCache) RangeResults(fn func(CacheKeyType, CacheValueType) bool) {
+	c.mu.Lock()
+	keys := make(map[CacheKeyType]CacheValueType, len(c.keys))
+	for key, val := range c.keys {
+		keys[key] = val
+	}
+	c.mu.Unlock()
+	for key, val := range keys {
+		if !c.cache.Has(key) {
+			continue
+		}
+		if !fn(key, val) {
+			return
+		}
+	}
+}
+
+// Stats returns the counters of the underlying cache.
+func (c *SessionCache) Stats() cache.CacheStats {
+	return c.cache.Stats()
+}
+
+// Size returns the number of entries in the underlying cache.
+func (c *SessionCache) Size() int {
+	return c.cache.Size()
+}
+
+// Invalidate removes the completed result cached for the given key from the
+// underlying cache, reporting whether there was one.
+func (c *SessionCache) Invalidate(key CacheKeyType) bool {
+	return c.cache.Invalidate(key)
+}
+
+// InvalidatePrefix removes the completed results cached for the keys with the
+// given prefix from the underlying cache, returning how many there were.
+//
+// Only the keys of results obtained through this session are considered, so
+// that a session can't evict results that only other sessions are using.
+func (c *SessionCache) InvalidatePrefix(prefix string) int {
+	var keys []CacheKeyType
+	c.mu.Lock()
+	for key := range c.keys {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+			delete(c.keys, key)
+		}
+	}
+	c.mu.Unlock()
+	var n int
+	for _, key := range keys {
+		if c.cache.Invalidate(key) {
+			n++
+		}
+	}
+	return n
+}
+
 func (c *SessionCache) ReleaseAndClose(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -192,6 +287,7 @@ func (c *SessionCache) ReleaseAndClose(ctx context.Context) error {
 		rerr = errors.Join(rerr, res.Release(ctx))
 	}
 	c.results = nil
+	c.keys = nil
 
 	return rerr
 }
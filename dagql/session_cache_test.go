@@ -89,3 +89,34 @@ func TestSessionCacheReleaseAndClose(t *testing.T) {
 		require.Equal(t, 0, c.Size())
 	})
 }
+
+func TestSessionCacheInvalidate(t *testing.T) {
+	ctx := t.Context()
+
+	base := cache.NewCache[string, AnyResult]()
+	sc := NewSessionCache(base)
+	for _, key := range []string{"a1", "a2", "b1"} {
+		_, err := sc.GetOrInitializeValue(ctx, cache.CacheKey[string]{ResultKey: key}, nil)
+		require.NoError(t, err)
+	}
+	other := NewSessionCache(base)
+	_, err := other.GetOrInitializeValue(ctx, cache.CacheKey[string]{ResultKey: "a3"}, nil)
+	require.NoError(t, err)
+
+	require.True(t, sc.Invalidate("b1"))
+	require.False(t, sc.Invalidate("b1"))
+	require.False(t, sc.Has("b1"))
+
+	require.Equal(t, 2, sc.InvalidatePrefix("a"))
+	require.Equal(t, 0, sc.InvalidatePrefix("a"))
+	require.False(t, sc.Has("a1"))
+	require.False(t, sc.Has("a2"))
+
+	// results of other sessions are left alone
+	require.True(t, sc.Has("a3"))
+	require.Equal(t, 1, other.InvalidatePrefix("a"))
+	require.False(t, other.Has("a3"))
+
+	require.NoError(t, sc.ReleaseAndClose(ctx))
+	require.NoError(t, other.ReleaseAndClose(ctx))
+}
@@ -0,0 +1,135 @@
+package dagql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// shutdownState tracks the operations executing on a server, so that it can
+// be shut down gracefully. It's shared by copies of the server.
+type shutdownState struct {
+	mu      sync.Mutex
+	closed  bool
+	running int
+	// drained is closed once the server is shutting down and no operations
+	// are running anymore.
+	drained   chan struct{}
+	drainOnce sync.Once
+}
+
+// begin registers an operation, returning false if the server is shutting
+// down.
+func (st *shutdownState) begin() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.closed {
+		return false
+	}
+	st.running++
+	return true
+}
+
+// join registers work that's part of an operation registered with begin but
+// outlives it, e.g. the payloads of a query delivered in the background. It
+// must be called before that operation ends. Like begin, it returns false if
+// the server is shutting down, in which case the work must not be started in
+// the background.
+func (st *shutdownState) join() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.closed {
+		return false
+	}
+	st.running++
+	return true
+}
+
+// end unregisters an operation registered with begin or join.
+func (st *shutdownState) end() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.running--
+	if st.closed && st.running == 0 {
+		st.drain()
+	}
+}
+
+// drain closes the drained channel, unless it already is. It must be called
+// with mu held.
+func (st *shutdownState) drain() {
+	st.drainOnce.Do(func() {
+		close(st.drained)
+	})
+}
+
+// close stops new operations from being registered, returning a channel that
+// is closed once the running ones have ended.
+func (st *shutdownState) close() <-chan struct{} {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.closed {
+		st.closed = true
+		st.drained = make(chan struct{})
+		if st.running == 0 {
+			st.drain()
+		}
+	}
+	return st.drained
+}
+
+func (st *shutdownState) numRunning() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.running
+}
+
+func (st *shutdownState) isClosed() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.closed
+}
+
+// GracefulShutdown stops the server from executing new operations, and waits
+// for the operations already executing to complete. New operations fail with
+// ErrCodeUnavailable, and requests served over HTTP by ServeHTTP, SSEHandler
+// and Handler with 503 Service Unavailable. Operations are complete once all
+// their payloads have been delivered, including those of @stream and @defer,
+// and once the resolvers of those that exceeded their timeout have returned.
+//
+// If ctx is done first, an error is returned with the number of operations
+// still executing.
+func (s *Server) GracefulShutdown(ctx context.Context) error {
+	select {
+	case <-s.shutdown.close():
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("shutdown: %d operations still running: %w", s.shutdown.numRunning(), context.Cause(ctx))
+	}
+}
+
+var errShuttingDown = Errorf(ErrCodeUnavailable, "server is shutting down")
+
+// checkShutdown fails the request with 503 Service Unavailable if the server
+// is shutting down, returning false.
+func (s *Server) checkShutdown(w http.ResponseWriter) bool {
+	if !s.shutdown.isClosed() {
+		return true
+	}
+	writeGraphQLResponse(w, http.StatusServiceUnavailable, &graphql.Response{
+		Errors: gqlErrs(errShuttingDown),
+	})
+	return false
+}
+
+// shutdownHandler rejects requests once the server is shutting down.
+func (s *Server) shutdownHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.checkShutdown(w) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
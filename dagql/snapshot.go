@@ -0,0 +1,97 @@
+package dagql
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// cacheSnapshotEntry is a line of a cache snapshot.
+//
+// Results can't be serialized in general, so an entry records the ID that
+// produced the result, which is loaded again to warm the cache.
+type cacheSnapshotEntry struct {
+	Digest digest.Digest `json:"digest"`
+	Value  string        `json:"value"`
+}
+
+// SnapshotCache writes the results cached by the server's session to w as
+// newline-delimited JSON, in a format that can be read back with WarmCache.
+// Results that only other sessions sharing the underlying cache obtained are
+// left out.
+//
+// Besides warming a server before it serves requests, a snapshot exported from
+// a failing session can be attached to a bug report and loaded into a local
+// server with WarmCache, to reproduce the state of its cache.
+func (s *Server) SnapshotCache(ctx context.Context, w io.Writer) error {
+	var entries []cacheSnapshotEntry
+	var rerr error
+	s.Cache.RangeResults(func(key CacheKeyType, val CacheValueType) bool {
+		if val == nil || val.ID() == nil {
+			return true
+		}
+		enc, err := val.ID().Encode()
+		if err != nil {
+			rerr = fmt.Errorf("encode ID of %s: %w", key, err)
+			return false
+		}
+		entries = append(entries, cacheSnapshotEntry{
+			Digest: digest.Digest(key),
+			Value:  enc,
+		})
+		return true
+	})
+	if rerr != nil {
+		return rerr
+	}
+	slices.SortFunc(entries, func(a, b cacheSnapshotEntry) int {
+		return strings.Compare(a.Digest.String(), b.Digest.String())
+	})
+
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WarmCache populates the cache from a snapshot written by SnapshotCache, so
+// that the results are ready before serving requests.
+func (s *Server) WarmCache(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	// IDs may be large, don't limit them to the default token size
+	scanner.Buffer(nil, 1<<30)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry cacheSnapshotEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if _, ok := s.Cache.Get(ctx, string(entry.Digest)); ok {
+			continue
+		}
+		var id call.ID
+		if err := id.Decode(entry.Value); err != nil {
+			return fmt.Errorf("line %d: decode ID: %w", line, err)
+		}
+		if _, err := s.LoadType(ctx, &id); err != nil {
+			return fmt.Errorf("line %d: load %s: %w", line, entry.Digest, err)
+		}
+	}
+	return scanner.Err()
+}
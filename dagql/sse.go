@@ -0,0 +1,65 @@
+package dagql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// SSEHandler returns a handler that serves GraphQL requests as server-sent
+// events, following the "distinct connections" mode of the GraphQL over SSE
+// protocol: the result of the operation is sent as a "next" event, followed by
+// a "complete" event.
+//
+// Requests are read the same way as by ServeHTTP.
+func (s *Server) SSEHandler() http.Handler {
+	return http.HandlerFunc(s.serveSSE)
+}
+
+func (s *Server) serveSSE(w http.ResponseWriter, r *http.Request) {
+	if !s.checkShutdown(w) || !s.checkSchemaVersion(w, r) {
+		return
+	}
+	params, ok := s.readGraphQLParams(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeGraphQLResponse(w, http.StatusInternalServerError, &graphql.Response{
+			Errors: gqlErrs(NewError(ErrCodeInternal, errors.New("streaming is not supported"))),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	// let the client know the stream is open before the operation completes
+	flusher.Flush()
+
+	_, res := s.execParams(r, params)
+	if err := writeSSEEvent(w, "next", res); err != nil {
+		return
+	}
+	_ = writeSSEEvent(w, "complete", nil)
+	flusher.Flush()
+}
+
+// writeSSEEvent writes an event with the given payload encoded as JSON as its
+// data, if any.
+func writeSSEEvent(w http.ResponseWriter, event string, payload any) error {
+	if payload == nil {
+		_, err := fmt.Fprintf(w, "event: %s\ndata:\n\n", event)
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
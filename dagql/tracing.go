@@ -1,7 +1,13 @@
 package dagql
 
 import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -10,3 +16,86 @@ const InstrumentationLibrary = "dagger.io/dagql"
 func Tracer() trace.Tracer {
 	return otel.Tracer(InstrumentationLibrary)
 }
+
+// WithTracer returns a copy of the server that starts a span named
+// `<TypeName>.<fieldName>` around the resolution of every field in a query.
+func (s *Server) WithTracer(tracer trace.Tracer) *Server {
+	cp := *s
+	cp.tracer = tracer
+	return &cp
+}
+
+type traceIDCtx struct{}
+
+// TraceID returns the correlation ID of the request being executed, which is
+// also reported in the traceId extension of every error in its response. It's
+// the trace ID of the request's span, if it has one, or a random UUID
+// otherwise. Outside of a request, it returns "".
+func (s *Server) TraceID(ctx context.Context) string {
+	return traceIDFromContext(ctx)
+}
+
+func traceIDFromContext(ctx context.Context) string {
+	id, _ := FromContext[string](ctx, traceIDCtx{})
+	return id
+}
+
+// newTraceID returns the correlation ID of a new request.
+func newTraceID(ctx context.Context) string {
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		return spanCtx.TraceID().String()
+	}
+	return uuid.NewString()
+}
+
+// withTraceID executes requests with the given handler, setting the same trace
+// ID in the context of each call and the errors of each response.
+func withTraceID(handler graphql.ResponseHandler) graphql.ResponseHandler {
+	var traceID string
+	return func(ctx context.Context) *graphql.Response {
+		if traceID == "" {
+			traceID = newTraceID(ctx)
+		}
+		res := handler(context.WithValue(ctx, traceIDCtx{}, traceID))
+		setTraceID(res, traceID)
+		return res
+	}
+}
+
+// setTraceID sets the traceId extension of the errors of the response.
+func setTraceID(res *graphql.Response, traceID string) {
+	if res == nil || traceID == "" {
+		return
+	}
+	for _, gqlErr := range res.Errors {
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = map[string]any{}
+		}
+		gqlErr.Extensions["traceId"] = traceID
+	}
+}
+
+// selectionSpanAttrs returns the span attributes describing the arguments of a
+// selection, omitting the values of sensitive arguments.
+func selectionSpanAttrs(spec FieldSpec, sel Selector) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(sel.Args))
+	for _, arg := range sel.Args {
+		key := "dagql.arg." + arg.Name
+		if argSpec, ok := spec.Args.Input(arg.Name, sel.View); ok && argSpec.Sensitive {
+			attrs = append(attrs, attribute.String(key, "***"))
+			continue
+		}
+		attrs = append(attrs, attribute.String(key, arg.Value.ToLiteral().ToAST().String()))
+	}
+	return attrs
+}
+
+// endSelectionSpan ends a span started for a field selection, recording the
+// error if it failed.
+func endSelectionSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
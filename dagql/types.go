@@ -53,6 +53,9 @@ type ObjectType interface {
 	Extend(spec FieldSpec, fun FieldFunc, cacheSpec CacheSpec)
 	// FieldSpec looks up a field spec by name.
 	FieldSpec(name string, view call.View) (FieldSpec, bool)
+	// FieldSpecs returns the specs of all fields in the given view, sorted by
+	// name.
+	FieldSpecs(view call.View) []FieldSpec
 }
 
 type IDType interface {
@@ -91,6 +94,10 @@ type AnyResult interface {
 
 	// WithPostCall returns a new AnyResult with the given post-call function attached to it.
 	WithPostCall(fn cache.PostCallFunc) AnyResult
+
+	// Annotation returns the value of the annotation set on the result for the
+	// given key, if any.
+	Annotation(key string) (string, bool)
 }
 
 // AnyObjectResult is an AnyResult that wraps a selectable value (i.e. a graph object)
@@ -863,15 +870,18 @@ func (i *ID[T]) UnmarshalJSON(p []byte) error {
 	return i.Decode(str)
 }
 
+// maxIDDisplayLen is the length IDs are abbreviated to in error messages.
+const maxIDDisplayLen = 200
+
 // Load loads the instance with the given ID from the server.
 func (i ID[T]) Load(ctx context.Context, server *Server) (res ObjectResult[T], _ error) {
 	val, err := server.Load(ctx, i.id)
 	if err != nil {
-		return res, fmt.Errorf("load %s: %w", i.id.Display(), err)
+		return res, fmt.Errorf("load %s: %w", i.id.DisplayString(maxIDDisplayLen), err)
 	}
 	obj, ok := val.(ObjectResult[T])
 	if !ok {
-		return res, fmt.Errorf("load %s: expected %T, got %T", i.id.Display(), obj, val)
+		return res, fmt.Errorf("load %s: expected %T, got %T", i.id.DisplayString(maxIDDisplayLen), obj, val)
 	}
 	return obj, nil
 }
@@ -887,6 +897,19 @@ type Enumerable interface {
 	Nth(int) (Typed, error)
 
 	NthValue(i int, enumID *call.ID) (AnyResult, error)
+
+	// Slice returns the elements from index from up to but excluding index to,
+	// with 0 representing the first entry.
+	Slice(from, to int) (Enumerable, error)
+}
+
+// checkSlice returns an error if from and to are not valid bounds for slicing
+// an Enumerable of length n.
+func checkSlice(from, to, n int) error {
+	if from < 0 || to > n || from > to {
+		return fmt.Errorf("slice bounds [%d:%d] out of range with length %d", from, to, n)
+	}
+	return nil
 }
 
 // Array is an array of GraphQL values.
@@ -990,6 +1013,35 @@ func ToArray[A any, T Typed](fn func(A) T, elems ...A) Array[T] {
 	return arr
 }
 
+// Filter returns a new Array with the elements of the array for which pred
+// returns true.
+func (arr Array[T]) Filter(pred func(T) bool) Array[T] {
+	filtered := Array[T]{}
+	for _, elem := range arr {
+		if pred(elem) {
+			filtered = append(filtered, elem)
+		}
+	}
+	return filtered
+}
+
+// Map creates a new Array by applying the given function to each element of
+// the given array.
+func Map[T, U Typed](arr Array[T], fn func(T) U) Array[U] {
+	return ToArray(fn, arr...)
+}
+
+// Reduce combines the elements of the given array into a single value, by
+// applying the given function to the result so far and each element in turn,
+// starting from init.
+func Reduce[T Typed, A any](arr Array[T], init A, fn func(A, T) A) A {
+	acc := init
+	for _, elem := range arr {
+		acc = fn(acc, elem)
+	}
+	return acc
+}
+
 func NewStringArray(elems ...string) Array[String] {
 	return ToArray(NewString, elems...)
 }
@@ -1055,6 +1107,13 @@ func (arr Array[T]) NthValue(i int, enumID *call.ID) (AnyResult, error) {
 	}, nil
 }
 
+func (arr Array[T]) Slice(from, to int) (Enumerable, error) {
+	if err := checkSlice(from, to, len(arr)); err != nil {
+		return nil, err
+	}
+	return arr[from:to], nil
+}
+
 type ResultArray[T Typed] []Result[T]
 
 var _ Typed = ResultArray[Typed]{}
@@ -1101,6 +1160,17 @@ func (arr ResultArray[T]) NthValue(i int, enumID *call.ID) (AnyResult, error) {
 	return inst, nil
 }
 
+func (arr ResultArray[T]) nthResult(i int) (AnyResult, error) {
+	return arr.NthValue(i, nil)
+}
+
+func (arr ResultArray[T]) Slice(from, to int) (Enumerable, error) {
+	if err := checkSlice(from, to, len(arr)); err != nil {
+		return nil, err
+	}
+	return arr[from:to], nil
+}
+
 type ObjectResultArray[T Typed] []ObjectResult[T]
 
 var _ Typed = ObjectResultArray[Typed]{}
@@ -1147,6 +1217,17 @@ func (arr ObjectResultArray[T]) NthValue(i int, enumID *call.ID) (AnyResult, err
 	return inst, nil
 }
 
+func (arr ObjectResultArray[T]) nthResult(i int) (AnyResult, error) {
+	return arr.NthValue(i, nil)
+}
+
+func (arr ObjectResultArray[T]) Slice(from, to int) (Enumerable, error) {
+	if err := checkSlice(from, to, len(arr)); err != nil {
+		return nil, err
+	}
+	return arr[from:to], nil
+}
+
 type enumValue interface {
 	Input
 	~string
@@ -1302,6 +1383,18 @@ func (e *EnumValues[T]) Install(srv *Server) {
 	srv.scalars[zero.Type().Name()] = e
 }
 
+// InstallEnum creates the enum type T with the given possible values and
+// installs it into the schema. Further values may be registered on the
+// returned enum.
+func InstallEnum[T enumValue](srv *Server, vals ...T) *EnumValues[T] {
+	enum := NewEnum(vals...)
+	srv.installLock.Lock()
+	defer srv.installLock.Unlock()
+	enum.Install(srv)
+	srv.invalidateSchemaCache()
+	return enum
+}
+
 type EnumValueName struct {
 	Enum string
 	Name string
@@ -1369,6 +1462,26 @@ func MustInputSpec(val Type) InputObjectSpec {
 	return spec
 }
 
+// InputFields defines the fields of an input object type T explicitly, for
+// when they shouldn't be derived from T's struct fields (see MustInputSpec).
+//
+// Values are decoded into T by field name via InputObject[T], so each field
+// must still correspond to a field of T.
+type InputFields[T Type] []InputSpec
+
+// Install installs the input object type into the schema.
+func (fields InputFields[T]) Install(server *Server) {
+	var t T
+	spec := InputObjectSpec{
+		Name:   t.TypeName(),
+		Fields: NewInputSpecs(fields...),
+	}
+	if desc, ok := any(t).(Descriptive); ok {
+		spec.Description = desc.TypeDescription()
+	}
+	spec.Install(server)
+}
+
 type InputObjectSpec struct {
 	Name        string
 	Description string
@@ -0,0 +1,71 @@
+package dagql
+
+import (
+	"slices"
+
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// union is an installed union type.
+type union struct {
+	typed   Typed
+	members []string
+}
+
+// InstallUnion installs the union type T into the schema, whose values may be
+// any of the given member object types.
+//
+// Selections on a union must use inline fragments (`... on Member { }`), which
+// are dispatched on the runtime type of the resolved value.
+func InstallUnion[T Typed](srv *Server, members ...ObjectType) {
+	var typed T
+	names := make([]string, len(members))
+	for i, member := range members {
+		names[i] = member.TypeName()
+	}
+	srv.installLock.Lock()
+	defer srv.installLock.Unlock()
+	srv.unions[typed.Type().Name()] = union{
+		typed:   typed,
+		members: names,
+	}
+	srv.invalidateSchemaCache()
+}
+
+// isUnionMember returns true if the named object type is a member of the named
+// union. The install lock must be held.
+func (s *Server) isUnionMember(typeName, unionName string) bool {
+	u, ok := s.unions[unionName]
+	return ok && slices.Contains(u.members, typeName)
+}
+
+// unionDefinitions adds the definitions of all installed unions to the schema,
+// and links each member object type to the unions it belongs to.
+func (s *Server) unionDefinitions(schema *ast.Schema, view call.View) {
+	for name, u := range s.unions {
+		var def *ast.Definition
+		if isType, ok := u.typed.(Definitive); ok {
+			def = isType.TypeDefinition(view)
+		} else {
+			def = &ast.Definition{
+				Kind:  ast.Union,
+				Name:  name,
+				Types: slices.Clone(u.members),
+			}
+		}
+		if isType, ok := u.typed.(Descriptive); ok {
+			def.Description = isType.TypeDescription()
+		}
+		schema.AddTypes(def)
+		for _, member := range u.members {
+			memberDef, ok := schema.Types[member]
+			if !ok {
+				continue
+			}
+			schema.AddPossibleType(name, memberDef)
+			schema.AddImplements(member, def)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package dagql
+
+import (
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// ValidateQuery validates a query against the current schema without executing
+// it, e.g. for linting queries in CI.
+//
+// The returned list holds the validation errors of the query, if any. An error
+// is returned if the query can't be parsed at all.
+func (s *Server) ValidateQuery(query string) (gqlerror.List, error) {
+	_, listErr, err := s.validateQuery(query)
+	return listErr, err
+}
+
+// parseQuery parses a query and validates it against the current schema.
+func (s *Server) parseQuery(query string) (*ast.QueryDocument, error) {
+	doc, listErr, err := s.validateQuery(query)
+	if err != nil {
+		return nil, gqlErrs(err)
+	}
+	if len(listErr) != 0 {
+		return nil, listErr
+	}
+	return doc, nil
+}
+
+func (s *Server) validateQuery(query string) (*ast.QueryDocument, gqlerror.List, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//nolint:staticcheck // annoying, but we can't easily switch to this without inconsistencies
+	listErr := validator.Validate(s.Schema(), doc)
+	for _, e := range listErr {
+		errcode.Set(e, errcode.ValidationFailed)
+	}
+	return doc, listErr, nil
+}
@@ -0,0 +1,96 @@
+package dagql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// SchemaVersionHeader is the response header reporting the version of the
+	// server's schema, if set with SetSchemaVersion.
+	SchemaVersionHeader = "X-Dagger-Schema-Version"
+
+	// MinSchemaVersionHeader is the request header with which a client may
+	// require a minimum version of the schema, e.g. "v1.2.0". Requests to a
+	// server with an older schema fail with 426 Upgrade Required.
+	MinSchemaVersionHeader = "X-Dagger-Min-Schema-Version"
+)
+
+// schemaVersionField is the root field reporting the version of the schema.
+const schemaVersionField = "schemaVersion"
+
+// SetSchemaVersion sets the semantic version of the server's schema, e.g.
+// "v1.2.0". It's served by a schemaVersion field on the root object, and in the
+// SchemaVersionHeader of responses served by ServeHTTP, SSEHandler and Handler.
+func (s *Server) SetSchemaVersion(v string) error {
+	if !semver.IsValid(v) {
+		return fmt.Errorf("invalid schema version %q: must be a semantic version like v1.2.3", v)
+	}
+	s.installLock.Lock()
+	first := s.schemaVersion == ""
+	s.schemaVersion = v
+	s.installLock.Unlock()
+	if first {
+		s.Root().ObjectType().Extend(FieldSpec{
+			Name:        schemaVersionField,
+			Description: "The semantic version of the schema.",
+			Type:        String(""),
+		}, func(ctx context.Context, _ AnyResult, _ map[string]Input) (AnyResult, error) {
+			return NewResultForCurrentID(ctx, String(s.SchemaVersion()))
+		}, CacheSpec{
+			DoNotCache: "The schema version may change.",
+		})
+	}
+	return nil
+}
+
+// SchemaVersion returns the version of the server's schema, or "" if it isn't
+// set.
+func (s *Server) SchemaVersion() string {
+	s.installLock.Lock()
+	defer s.installLock.Unlock()
+	return s.schemaVersion
+}
+
+// checkSchemaVersion sets the SchemaVersionHeader of the response, and fails
+// the request if the client requires a newer schema, returning false.
+func (s *Server) checkSchemaVersion(w http.ResponseWriter, r *http.Request) bool {
+	version := s.SchemaVersion()
+	if version != "" {
+		w.Header().Set(SchemaVersionHeader, version)
+	}
+	minVersion := r.Header.Get(MinSchemaVersionHeader)
+	if minVersion == "" {
+		return true
+	}
+	if !semver.IsValid(minVersion) {
+		writeGraphQLResponse(w, http.StatusBadRequest, &graphql.Response{
+			Errors: gqlErrs(Errorf(ErrCodeInvalidArgument, "invalid %s header %q: must be a semantic version", MinSchemaVersionHeader, minVersion)),
+		})
+		return false
+	}
+	if version == "" || semver.Compare(version, minVersion) < 0 {
+		if version == "" {
+			version = "unversioned"
+		}
+		writeGraphQLResponse(w, http.StatusUpgradeRequired, &graphql.Response{
+			Errors: gqlErrs(Errorf(ErrCodeInvalidArgument, "schema version %s is older than the required %s", version, minVersion)),
+		})
+		return false
+	}
+	return true
+}
+
+// schemaVersionHandler checks the schema version required by requests before
+// passing them to the handler.
+func (s *Server) schemaVersionHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.checkSchemaVersion(w, r) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
@@ -1,9 +1,11 @@
 package cache
 
 import (
+	"container/list"
 	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
 type Cache[K comparable, V any] interface {
@@ -29,8 +31,75 @@ type Cache[K comparable, V any] interface {
 		func(context.Context) (*ValueWithCallbacks[V], error),
 	) (Result[K, V], error)
 
+	// Return the completed result cached for the given key, if any, without
+	// initializing it. The returned result must be released like any other.
+	Get(context.Context, K) (Result[K, V], bool)
+
+	// Reports whether a completed result is cached for the given key, without
+	// counting a hit or marking the result as recently used.
+	Has(K) bool
+
+	// Calls fn with the key and value of each completed result in the cache,
+	// stopping early if fn returns false.
+	Range(fn func(K, V) bool)
+
+	// Removes the completed result cached for the given key, if any, so that
+	// the next call with that key runs again. Reports whether there was one.
+	// Callers holding the result may keep using it until they release it.
+	Invalidate(K) bool
+
 	// Returns the number of entries in the cache.
 	Size() int
+
+	// Returns hit/miss/eviction counters for the cache.
+	Stats() CacheStats
+}
+
+// CacheStats is a snapshot of a cache's counters.
+type CacheStats struct {
+	// Hits is the number of calls that were served by a completed result.
+	Hits uint64
+	// Misses is the number of calls that had to run (or wait on) a call.
+	Misses uint64
+	// Evictions is the number of completed results removed from the cache due
+	// to TTL expiration or the max size being exceeded.
+	Evictions uint64
+}
+
+// CacheOpts configures eviction of completed results from a Cache.
+//
+// Evicting a result only removes it from the cache's index; callers that
+// already hold the result may keep using it, and its OnRelease callback still
+// only runs once the last holder releases it.
+type CacheOpts struct {
+	// TTL is how long a completed result may be served from the cache. Zero
+	// means results never expire.
+	TTL time.Duration
+
+	// MaxSize is the maximum number of completed results to keep, evicting the
+	// least recently used results first. Zero means unbounded.
+	MaxSize int
+
+	// Remote, if set, is consulted for results that aren't cached locally,
+	// using RemoteCodec to encode and decode them.
+	Remote      RemoteCache
+	RemoteCodec RemoteCodec
+}
+
+type CacheOpt func(*CacheOpts)
+
+// WithTTL sets how long completed results may be served from the cache.
+func WithTTL(d time.Duration) CacheOpt {
+	return func(opts *CacheOpts) {
+		opts.TTL = d
+	}
+}
+
+// WithMaxSize sets the maximum number of completed results kept in the cache.
+func WithMaxSize(n int) CacheOpt {
+	return func(opts *CacheOpts) {
+		opts.MaxSize = n
+	}
 }
 
 type Result[K comparable, V any] interface {
@@ -74,19 +143,32 @@ type ValueWithCallbacks[V any] struct {
 
 var ErrCacheRecursiveCall = fmt.Errorf("recursive call detected")
 
-func NewCache[K comparable, V any]() Cache[K, V] {
-	return &cache[K, V]{}
+func NewCache[K comparable, V any](opts ...CacheOpt) Cache[K, V] {
+	c := &cache[K, V]{
+		lru: list.New(),
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
 }
 
 type cache[K comparable, V any] struct {
 	mu sync.Mutex
 
+	opts CacheOpts
+
 	// calls that are in progress, keyed by a combination of the result key and the concurrency key
 	// two calls with the same result+concurrency key will be "single-flighted" (only one will actually run)
 	ongoingCalls map[CacheKey[K]]*result[K, V]
 
 	// calls that have completed successfully and are cached, keyed just by the result key
 	completedCalls map[K]*result[K, V]
+
+	// completed calls ordered from most to least recently used
+	lru *list.List
+
+	stats CacheStats
 }
 
 var _ Cache[int, int] = &cache[int, int]{}
@@ -106,6 +188,10 @@ type result[K comparable, V any] struct {
 	waiters int
 
 	refCount int
+
+	// set while the result is indexed in completedCalls
+	lruElem     *list.Element
+	completedAt time.Time
 }
 
 // perCallResult wraps result with metadata that is specific to a single call,
@@ -135,6 +221,116 @@ func (c *cache[K, V]) Size() int {
 	return len(c.ongoingCalls) + len(c.completedCalls)
 }
 
+func (c *cache[K, V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// lookupCompleted returns the completed result for the given key, evicting it
+// first if it has outlived the TTL. The caller must hold c.mu.
+func (c *cache[K, V]) lookupCompleted(key K) (*result[K, V], bool) {
+	res, ok := c.completedCalls[key]
+	if !ok {
+		return nil, false
+	}
+	if c.opts.TTL > 0 && time.Since(res.completedAt) > c.opts.TTL {
+		c.evict(res)
+		return nil, false
+	}
+	c.lru.MoveToFront(res.lruElem)
+	return res, true
+}
+
+// addCompleted indexes a newly completed result and evicts the least recently
+// used results if the cache exceeds its max size. The caller must hold c.mu.
+func (c *cache[K, V]) addCompleted(res *result[K, V]) {
+	c.completedCalls[res.key.ResultKey] = res
+	res.completedAt = time.Now()
+	res.lruElem = c.lru.PushFront(res)
+	if c.opts.MaxSize <= 0 {
+		return
+	}
+	for c.lru.Len() > c.opts.MaxSize {
+		c.evict(c.lru.Back().Value.(*result[K, V]))
+	}
+}
+
+// evict removes a completed result from the index without releasing it. The
+// caller must hold c.mu.
+func (c *cache[K, V]) evict(res *result[K, V]) {
+	c.unindex(res)
+	c.stats.Evictions++
+}
+
+// unindex removes a result from completedCalls and the LRU list, if it is
+// still the indexed result for its key. The caller must hold c.mu.
+func (c *cache[K, V]) unindex(res *result[K, V]) {
+	if c.completedCalls[res.key.ResultKey] == res {
+		delete(c.completedCalls, res.key.ResultKey)
+	}
+	if res.lruElem != nil {
+		c.lru.Remove(res.lruElem)
+		res.lruElem = nil
+	}
+}
+
+func (c *cache[K, V]) Get(_ context.Context, key K) (Result[K, V], bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res, ok := c.lookupCompleted(key)
+	if !ok {
+		return nil, false
+	}
+	res.refCount++
+	c.stats.Hits++
+	return &perCallResult[K, V]{
+		result:   res,
+		hitCache: true,
+	}, true
+}
+
+func (c *cache[K, V]) Has(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res, ok := c.completedCalls[key]
+	if !ok {
+		return false
+	}
+	return c.opts.TTL <= 0 || time.Since(res.completedAt) <= c.opts.TTL
+}
+
+func (c *cache[K, V]) Invalidate(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res, ok := c.completedCalls[key]
+	if !ok {
+		return false
+	}
+	c.unindex(res)
+	return true
+}
+
+func (c *cache[K, V]) Range(fn func(K, V) bool) {
+	c.mu.Lock()
+	results := make([]*result[K, V], 0, len(c.completedCalls))
+	for _, res := range c.completedCalls {
+		results = append(results, res)
+	}
+	c.mu.Unlock()
+
+	// call fn without holding the lock, so that it may use the cache
+	for _, res := range results {
+		if !fn(res.key.ResultKey, res.val) {
+			return
+		}
+	}
+}
+
 func (c *cache[K, V]) GetOrInitializeValue(
 	ctx context.Context,
 	key CacheKey[K],
@@ -192,8 +388,9 @@ func (c *cache[K, V]) GetOrInitializeWithCallbacks(
 		c.completedCalls = make(map[K]*result[K, V])
 	}
 
-	if res, ok := c.completedCalls[key.ResultKey]; ok {
+	if res, ok := c.lookupCompleted(key.ResultKey); ok {
 		res.refCount++
+		c.stats.Hits++
 		c.mu.Unlock()
 		return &perCallResult[K, V]{
 			result:   res,
@@ -201,6 +398,8 @@ func (c *cache[K, V]) GetOrInitializeWithCallbacks(
 		}, nil
 	}
 
+	c.stats.Misses++
+
 	if key.ConcurrencyKey != zeroKey {
 		if res, ok := c.ongoingCalls[key]; ok {
 			// already an ongoing call
@@ -229,7 +428,7 @@ func (c *cache[K, V]) GetOrInitializeWithCallbacks(
 
 	go func() {
 		defer close(res.waitCh)
-		valWithCallbacks, err := fn(callCtx)
+		valWithCallbacks, err := c.initialize(callCtx, key.ResultKey, fn)
 		res.err = err
 		if valWithCallbacks != nil {
 			res.val = valWithCallbacks.Value
@@ -281,10 +480,10 @@ func (c *cache[K, V]) wait(ctx context.Context, res *result[K, V]) (*perCallResu
 
 	if err == nil {
 		delete(c.ongoingCalls, res.key)
-		if existingRes, ok := c.completedCalls[res.key.ResultKey]; ok {
+		if existingRes, ok := c.lookupCompleted(res.key.ResultKey); ok {
 			res = existingRes
 		} else {
-			c.completedCalls[res.key.ResultKey] = res
+			c.addCompleted(res)
 		}
 
 		res.refCount++
@@ -297,7 +496,7 @@ func (c *cache[K, V]) wait(ctx context.Context, res *result[K, V]) (*perCallResu
 	if res.refCount == 0 && res.waiters == 0 {
 		// error happened and no refs left, delete it now
 		delete(c.ongoingCalls, res.key)
-		delete(c.completedCalls, res.key.ResultKey)
+		c.unindex(res)
 	}
 	return nil, err
 }
@@ -322,7 +521,7 @@ func (res *result[K, V]) Release(ctx context.Context) error {
 	if res.refCount == 0 && res.waiters == 0 {
 		// no refs left and no one waiting on it, delete from cache
 		delete(res.cache.ongoingCalls, res.key)
-		delete(res.cache.completedCalls, res.key.ResultKey)
+		res.cache.unindex(res)
 		onRelease = res.onRelease
 	}
 	res.cache.mu.Unlock()
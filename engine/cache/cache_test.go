@@ -416,3 +416,206 @@ func TestSkipDedupe(t *testing.T) {
 		t.Fatal("timed out waiting for resCh2")
 	}
 }
+
+func TestCacheEviction(t *testing.T) {
+	t.Parallel()
+	t.Run("max size", func(t *testing.T) {
+		t.Parallel()
+		c := NewCache[int, int](WithMaxSize(2))
+		ctx := context.Background()
+
+		initCount := 0
+		get := func(key int) Result[int, int] {
+			res, err := c.GetOrInitialize(ctx, CacheKey[int]{ResultKey: key}, func(_ context.Context) (int, error) {
+				initCount++
+				return key, nil
+			})
+			assert.NilError(t, err)
+			return res
+		}
+
+		get(1)
+		get(2)
+		get(1) // 1 is now the most recently used
+		get(3) // evicts 2
+		assert.Equal(t, 3, initCount)
+		assert.Equal(t, 2, c.Size())
+
+		get(1)
+		assert.Equal(t, 3, initCount)
+		get(2)
+		assert.Equal(t, 4, initCount)
+
+		stats := c.Stats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(4), stats.Misses)
+		assert.Equal(t, uint64(2), stats.Evictions)
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		t.Parallel()
+		c := NewCache[int, int](WithTTL(10 * time.Millisecond))
+		ctx := context.Background()
+
+		initCount := 0
+		initFn := func(_ context.Context) (int, error) {
+			initCount++
+			return initCount, nil
+		}
+
+		res, err := c.GetOrInitialize(ctx, CacheKey[int]{ResultKey: 1}, initFn)
+		assert.NilError(t, err)
+		assert.Equal(t, 1, res.Result())
+
+		res, err = c.GetOrInitialize(ctx, CacheKey[int]{ResultKey: 1}, initFn)
+		assert.NilError(t, err)
+		assert.Equal(t, 1, res.Result())
+		assert.Assert(t, res.HitCache())
+
+		time.Sleep(20 * time.Millisecond)
+
+		res, err = c.GetOrInitialize(ctx, CacheKey[int]{ResultKey: 1}, initFn)
+		assert.NilError(t, err)
+		assert.Equal(t, 2, res.Result())
+		assert.Assert(t, !res.HitCache())
+		assert.Equal(t, uint64(1), c.Stats().Evictions)
+	})
+
+	t.Run("evicted results release once unreferenced", func(t *testing.T) {
+		t.Parallel()
+		c := NewCache[int, int](WithMaxSize(1))
+		ctx := context.Background()
+
+		released := false
+		res1, err := c.GetOrInitializeWithCallbacks(ctx, CacheKey[int]{ResultKey: 1}, func(_ context.Context) (*ValueWithCallbacks[int], error) {
+			return &ValueWithCallbacks[int]{Value: 1, OnRelease: func(context.Context) error {
+				released = true
+				return nil
+			}}, nil
+		})
+		assert.NilError(t, err)
+
+		// evicts 1, which is still referenced
+		res2, err := c.GetOrInitialize(ctx, CacheKey[int]{ResultKey: 2}, func(_ context.Context) (int, error) {
+			return 2, nil
+		})
+		assert.NilError(t, err)
+		assert.Equal(t, 1, c.Size())
+		assert.Assert(t, !released)
+
+		assert.NilError(t, res1.Release(ctx))
+		assert.Assert(t, released)
+
+		// releasing the evicted result must not touch the live entry
+		assert.Equal(t, 1, c.Size())
+		assert.NilError(t, res2.Release(ctx))
+		assert.Equal(t, 0, c.Size())
+	})
+}
+
+func TestCacheGet(t *testing.T) {
+	t.Parallel()
+	c := NewCache[int, int]()
+	ctx := context.Background()
+
+	_, ok := c.Get(ctx, 1)
+	assert.Assert(t, !ok)
+
+	released := false
+	res1, err := c.GetOrInitializeWithCallbacks(ctx, CacheKey[int]{ResultKey: 1}, func(_ context.Context) (*ValueWithCallbacks[int], error) {
+		return &ValueWithCallbacks[int]{Value: 1, OnRelease: func(context.Context) error {
+			released = true
+			return nil
+		}}, nil
+	})
+	assert.NilError(t, err)
+
+	res2, ok := c.Get(ctx, 1)
+	assert.Assert(t, ok)
+	assert.Equal(t, 1, res2.Result())
+	assert.Assert(t, res2.HitCache())
+	assert.Equal(t, uint64(1), c.Stats().Hits)
+
+	// the result stays cached until both holders release it
+	assert.NilError(t, res1.Release(ctx))
+	assert.Assert(t, !released)
+	assert.NilError(t, res2.Release(ctx))
+	assert.Assert(t, released)
+
+	_, ok = c.Get(ctx, 1)
+	assert.Assert(t, !ok)
+}
+
+func TestCacheHas(t *testing.T) {
+	t.Parallel()
+	c := NewCache[int, int]()
+	ctx := context.Background()
+
+	assert.Assert(t, !c.Has(1))
+
+	res, err := c.GetOrInitializeValue(ctx, CacheKey[int]{ResultKey: 1}, 1)
+	assert.NilError(t, err)
+	assert.Assert(t, c.Has(1))
+	assert.Assert(t, !c.Has(2))
+	// checking doesn't count as a hit
+	assert.Equal(t, uint64(0), c.Stats().Hits)
+
+	assert.NilError(t, res.Release(ctx))
+	assert.Assert(t, !c.Has(1))
+}
+
+func TestCacheRange(t *testing.T) {
+	t.Parallel()
+	c := NewCache[int, int]()
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		_, err := c.GetOrInitializeValue(ctx, CacheKey[int]{ResultKey: i}, i*10)
+		assert.NilError(t, err)
+	}
+
+	seen := map[int]int{}
+	c.Range(func(key, val int) bool {
+		seen[key] = val
+		return true
+	})
+	assert.DeepEqual(t, seen, map[int]int{1: 10, 2: 20, 3: 30})
+
+	count := 0
+	c.Range(func(int, int) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	t.Parallel()
+	c := NewCache[int, int]()
+	ctx := context.Background()
+
+	assert.Assert(t, !c.Invalidate(1))
+
+	res1, err := c.GetOrInitializeValue(ctx, CacheKey[int]{ResultKey: 1}, 1)
+	assert.NilError(t, err)
+	assert.Assert(t, c.Invalidate(1))
+	assert.Assert(t, !c.Has(1))
+	assert.Assert(t, !c.Invalidate(1))
+	// invalidating doesn't count as an eviction
+	assert.Equal(t, uint64(0), c.Stats().Evictions)
+
+	// the invalidated result is still usable by its holder
+	assert.Equal(t, 1, res1.Result())
+
+	// the next call runs again
+	res2, err := c.GetOrInitializeValue(ctx, CacheKey[int]{ResultKey: 1}, 2)
+	assert.NilError(t, err)
+	assert.Assert(t, !res2.HitCache())
+	assert.Equal(t, 2, res2.Result())
+
+	// releasing the invalidated result leaves the new one cached
+	assert.NilError(t, res1.Release(ctx))
+	assert.Assert(t, c.Has(1))
+	assert.NilError(t, res2.Release(ctx))
+	assert.Assert(t, !c.Has(1))
+}
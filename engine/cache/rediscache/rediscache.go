@@ -0,0 +1,47 @@
+// Package rediscache implements a cache.RemoteCache backed by Redis.
+package rediscache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dagger/dagger/engine/cache"
+)
+
+// RemoteCache stores encoded results in Redis.
+type RemoteCache struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+var _ cache.RemoteCache = (*RemoteCache)(nil)
+
+// New returns a remote cache storing results with the given client. Keys are
+// prefixed with prefix, and expire after ttl if it is non-zero.
+func New(client redis.UniversalClient, prefix string, ttl time.Duration) *RemoteCache {
+	return &RemoteCache{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+func (c *RemoteCache) Get(ctx context.Context, key digest.Digest) ([]byte, bool, error) {
+	data, err := c.client.Get(ctx, c.prefix+key.String()).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (c *RemoteCache) Set(ctx context.Context, key digest.Digest, value []byte) error {
+	return c.client.Set(ctx, c.prefix+key.String(), value, c.ttl).Err()
+}
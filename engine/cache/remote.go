@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/dagger/dagger/engine/slog"
+)
+
+// RemoteCache is a store of encoded results shared between caches, e.g. by
+// several engines. It is consulted when a result isn't cached locally.
+type RemoteCache interface {
+	// Get returns the encoded result stored for the given key, if any.
+	Get(ctx context.Context, key digest.Digest) ([]byte, bool, error)
+	// Set stores the encoded result for the given key.
+	Set(ctx context.Context, key digest.Digest, value []byte) error
+}
+
+// RemoteCodec converts between cached values and the bytes stored in a
+// RemoteCache.
+type RemoteCodec interface {
+	// Encode encodes the value. It may return nil data for values that should
+	// not be stored remotely.
+	Encode(ctx context.Context, value any) ([]byte, error)
+	// Decode decodes a value previously encoded with Encode.
+	Decode(ctx context.Context, data []byte) (any, error)
+}
+
+// WithRemote makes the cache fall through to the given remote cache when a
+// result isn't cached locally, and store newly initialized results in it.
+//
+// Only caches whose keys are strings or digests can use a remote cache. Errors
+// talking to the remote cache are logged and otherwise treated as misses.
+func WithRemote(remote RemoteCache, codec RemoteCodec) CacheOpt {
+	return func(opts *CacheOpts) {
+		opts.Remote = remote
+		opts.RemoteCodec = codec
+	}
+}
+
+// initialize initializes the value for the given key, loading it from the
+// remote cache if possible.
+func (c *cache[K, V]) initialize(
+	ctx context.Context,
+	key K,
+	fn func(context.Context) (*ValueWithCallbacks[V], error),
+) (*ValueWithCallbacks[V], error) {
+	remote, codec := c.opts.Remote, c.opts.RemoteCodec
+	dgst, ok := remoteKey(key)
+	if remote == nil || codec == nil || !ok {
+		return fn(ctx)
+	}
+
+	if val, ok, err := getRemote[V](ctx, remote, codec, dgst); err != nil {
+		slog.WarnContext(ctx, "failed to get result from remote cache", "key", dgst, "error", err)
+	} else if ok {
+		return &ValueWithCallbacks[V]{Value: val}, nil
+	}
+
+	valWithCallbacks, err := fn(ctx)
+	if err != nil || valWithCallbacks == nil {
+		return valWithCallbacks, err
+	}
+	if err := setRemote(ctx, remote, codec, dgst, valWithCallbacks.Value); err != nil {
+		slog.WarnContext(ctx, "failed to store result in remote cache", "key", dgst, "error", err)
+	}
+	return valWithCallbacks, nil
+}
+
+func getRemote[V any](ctx context.Context, remote RemoteCache, codec RemoteCodec, key digest.Digest) (V, bool, error) {
+	var zero V
+	data, ok, err := remote.Get(ctx, key)
+	if err != nil || !ok {
+		return zero, false, err
+	}
+	decoded, err := codec.Decode(ctx, data)
+	if err != nil {
+		return zero, false, fmt.Errorf("decode: %w", err)
+	}
+	val, ok := decoded.(V)
+	if !ok {
+		return zero, false, fmt.Errorf("decode: unexpected value type %T", decoded)
+	}
+	return val, true, nil
+}
+
+func setRemote(ctx context.Context, remote RemoteCache, codec RemoteCodec, key digest.Digest, val any) error {
+	data, err := codec.Encode(ctx, val)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	if data == nil {
+		return nil
+	}
+	return remote.Set(ctx, key, data)
+}
+
+// remoteKey returns the key of a result in a remote cache.
+func remoteKey[K comparable](key K) (digest.Digest, bool) {
+	switch key := any(key).(type) {
+	case digest.Digest:
+		return key, true
+	case string:
+		return digest.Digest(key), true
+	default:
+		return "", false
+	}
+}
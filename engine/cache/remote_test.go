@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+)
+
+type memRemote struct {
+	mu   sync.Mutex
+	data map[digest.Digest][]byte
+}
+
+func (r *memRemote) Get(_ context.Context, key digest.Digest) ([]byte, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, ok := r.data[key]
+	return data, ok, nil
+}
+
+func (r *memRemote) Set(_ context.Context, key digest.Digest, value []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.data == nil {
+		r.data = map[digest.Digest][]byte{}
+	}
+	r.data[key] = value
+	return nil
+}
+
+type intCodec struct{}
+
+func (intCodec) Encode(_ context.Context, value any) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (intCodec) Decode(_ context.Context, data []byte) (any, error) {
+	var val int
+	err := json.Unmarshal(data, &val)
+	return val, err
+}
+
+func TestCacheRemote(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	remote := &memRemote{}
+
+	initCount := 0
+	initFn := func(_ context.Context) (int, error) {
+		initCount++
+		return 42, nil
+	}
+
+	c1 := NewCache[string, int](WithRemote(remote, intCodec{}))
+	res, err := c1.GetOrInitialize(ctx, CacheKey[string]{ResultKey: "sha256:abc"}, initFn)
+	assert.NilError(t, err)
+	assert.Equal(t, 42, res.Result())
+	assert.Equal(t, 1, initCount)
+	assert.DeepEqual(t, remote.data, map[digest.Digest][]byte{"sha256:abc": []byte("42")})
+
+	// a different cache sharing the remote doesn't need to initialize it again
+	c2 := NewCache[string, int](WithRemote(remote, intCodec{}))
+	res, err = c2.GetOrInitialize(ctx, CacheKey[string]{ResultKey: "sha256:abc"}, initFn)
+	assert.NilError(t, err)
+	assert.Equal(t, 42, res.Result())
+	assert.Equal(t, 1, initCount)
+
+	// uncached calls never touch the remote
+	res, err = c2.GetOrInitialize(ctx, CacheKey[string]{}, initFn)
+	assert.NilError(t, err)
+	assert.Equal(t, 42, res.Result())
+	assert.Equal(t, 2, initCount)
+	assert.Equal(t, 1, len(remote.data))
+}
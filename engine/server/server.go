@@ -184,6 +184,10 @@ type NewServerOpts struct {
 	Name           string
 	Config         *config.Config
 	BuildkitConfig *bkconfig.Config
+
+	// DagqlCacheOpts configures eviction for the dagql cache shared by all
+	// sessions.
+	DagqlCacheOpts []cache.CacheOpt
 }
 
 //nolint:gocyclo
@@ -210,7 +214,7 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 			SearchDomains: bkcfg.DNS.SearchDomains,
 		},
 
-		baseDagqlCache: cache.NewCache[string, dagql.AnyResult](),
+		baseDagqlCache: cache.NewCache[string, dagql.AnyResult](opts.DagqlCacheOpts...),
 		daggerSessions: make(map[string]*daggerSession),
 		locker:         locker.New(),
 	}